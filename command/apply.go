@@ -0,0 +1,389 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/gurleensethi/cradle/internal/diff"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/gurleensethi/cradle/internal/types"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v3"
+)
+
+// Conflict policies decide what happens to project files that differ from
+// the rendered template.
+const (
+	conflictPrompt    = "prompt"
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	// conflictNew writes the rendered file next to the existing one with a .new suffix.
+	conflictNew = "new"
+)
+
+var conflictPolicies = []string{conflictPrompt, conflictSkip, conflictOverwrite, conflictNew}
+
+// Apply returns the apply command for rendering a template into an existing project.
+func Apply() *cli.Command {
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "Render a template into an existing project",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "template",
+				UsageText: "name of the template to apply",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+			&cli.StringArg{
+				Name:      "project",
+				UsageText: "name or path of the project, defaults to the project in the current directory",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "set a template input as `Key=Value`, can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "values",
+				Usage: "read template inputs from a YAML or JSON `file`",
+			},
+			&cli.BoolFlag{
+				Name:  "no-input",
+				Usage: "never prompt, use remembered answers and defaults for inputs that are not set",
+			},
+			&cli.BoolFlag{
+				Name:  "fresh",
+				Usage: "do not offer the answers given last time as defaults",
+			},
+			&cli.StringFlag{
+				Name:  "conflict",
+				Usage: "what to do with files that differ from the template: `prompt`, skip, overwrite or new (write a .new file), defaults to prompt in a terminal and skip otherwise",
+			},
+		},
+		// Values passed with --set may contain commas.
+		DisableSliceFlagSeparator: true,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			templateName := c.StringArg("template")
+			if templateName == "" {
+				return fmt.Errorf("provide a template name")
+			}
+
+			noInput := c.Bool("no-input") || !isatty.IsTerminal(os.Stdin.Fd())
+
+			conflict := c.String("conflict")
+			if conflict == "" {
+				conflict = conflictPrompt
+				if noInput {
+					conflict = conflictSkip
+				}
+			}
+			if !slices.Contains(conflictPolicies, conflict) {
+				return fmt.Errorf("invalid --conflict %q, expected one of %s", conflict, strings.Join(conflictPolicies, ", "))
+			}
+			if conflict == conflictPrompt && noInput {
+				return fmt.Errorf("--conflict prompt needs a terminal, choose skip, overwrite or new")
+			}
+
+			inputValues, err := readInputValues(c.String("values"), c.StringSlice("set"))
+			if err != nil {
+				return err
+			}
+
+			return applyTemplate(applyTemplateParams{
+				Template:    templateName,
+				Project:     c.StringArg("project"),
+				InputValues: inputValues,
+				NoInput:     noInput,
+				Fresh:       c.Bool("fresh"),
+				Conflict:    conflict,
+			})
+		},
+	}
+}
+
+type applyTemplateParams struct {
+	Template string
+	// Project is the name or path of the project, empty for the project in the working directory.
+	Project string
+	// InputValues are template inputs provided upfront, they take precedence over remembered answers.
+	InputValues map[string]string
+	NoInput     bool
+	// Fresh ignores the answers remembered for the template.
+	Fresh    bool
+	Conflict string
+}
+
+// fileChange is a rendered file and what happens to it in the project.
+type fileChange struct {
+	file cradleTemplate.RenderedFile
+	// existing is the current content of the file, nil for new files.
+	existing []byte
+	// action is create, or the conflict policy picked for a changed file.
+	action string
+}
+
+const fileCreate = "create"
+
+// applyTemplate renders a template into a registered project. Answers to the
+// template inputs are recorded on the project and reused when the template
+// is applied again.
+func applyTemplate(params applyTemplateParams) error {
+	project, err := findApplyProject(params.Project)
+	if err != nil {
+		return err
+	}
+
+	templateData, err := cradleTemplate.GetTemplate(params.Template)
+	if err != nil {
+		if errors.Is(err, cradleTemplate.ErrNotExists) {
+			return fmt.Errorf("template %s does not exist", params.Template)
+		}
+		return err
+	}
+
+	for _, warning := range templateData.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	inputValues := rememberedInputs(project, templateData)
+	maps.Copy(inputValues, params.InputValues)
+
+	history, err := inputHistory(templateData, params.Fresh)
+	if err != nil {
+		return err
+	}
+
+	// Applying the template again renders the same times and UUIDs as before.
+	generated := types.Generated{Time: time.Now()}
+	if applied, found := project.AppliedTemplate(params.Template); found && !applied.Generated.Time.IsZero() {
+		generated = applied.Generated
+	}
+
+	files, templateInput, err := renderProjectFiles(templateData, filepath.Base(project.Path), cradleTemplate.InputOptions{
+		Values:    inputValues,
+		NoInput:   params.NoInput,
+		History:   history,
+		Generated: &generated,
+	})
+	if err != nil {
+		return err
+	}
+
+	var changes []fileChange
+	unchanged := 0
+
+	for _, file := range files {
+		existing, err := os.ReadFile(filepath.Join(project.Path, filepath.FromSlash(file.Path)))
+		if errors.Is(err, os.ErrNotExist) {
+			changes = append(changes, fileChange{file: file, action: fileCreate})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(existing, file.Content) {
+			unchanged++
+			continue
+		}
+
+		changes = append(changes, fileChange{file: file, existing: existing, action: params.Conflict})
+	}
+
+	for i, change := range changes {
+		printFileDiff(change.file.Path, change.existing, change.file.Content)
+
+		if change.action == conflictPrompt {
+			changes[i].action, err = promptConflict(change.file.Path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, change := range changes {
+		filePath := filepath.Join(project.Path, filepath.FromSlash(change.file.Path))
+
+		switch change.action {
+		case conflictSkip:
+			counts[conflictSkip]++
+			continue
+		case conflictNew:
+			filePath += ".new"
+		}
+
+		err = writeProjectFile(filePath, change.file.Content, change.file.Mode)
+		if err != nil {
+			return err
+		}
+
+		counts[change.action]++
+	}
+
+	version, err := cradleTemplate.SaveVersion(templateData)
+	if err != nil {
+		return err
+	}
+
+	project.SetAppliedTemplate(types.AppliedTemplate{
+		Name:      params.Template,
+		Version:   version,
+		Inputs:    cradleTemplate.FormatInputs(templateData, templateInput),
+		AppliedAt: time.Now(),
+		Generated: generated,
+	})
+
+	err = config.UpdateProject(project)
+	if err != nil {
+		return fmt.Errorf("record applied template: %w", err)
+	}
+
+	if !params.NoInput {
+		rememberInputs(templateData, templateInput)
+	}
+
+	fmt.Printf("Applied %s to %s: %d created, %d overwritten, %d written as .new, %d skipped, %d unchanged\n",
+		params.Template, project.GetPathWithTruncatedHome(),
+		counts[fileCreate], counts[conflictOverwrite], counts[conflictNew], counts[conflictSkip], unchanged)
+
+	return nil
+}
+
+// findApplyProject looks up the project by name or path, or the project
+// containing the working directory when query is empty.
+func findApplyProject(query string) (types.CradleProject, error) {
+	if query != "" {
+		return openProject(query)
+	}
+
+	workDirPath, err := os.Getwd()
+	if err != nil {
+		return types.CradleProject{}, err
+	}
+
+	var found types.CradleProject
+	config.ForEachProject(func(project types.CradleProject) bool {
+		relPath, err := filepath.Rel(project.Path, workDirPath)
+		if err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) &&
+			len(project.Path) > len(found.Path) {
+			found = project
+		}
+		return true
+	})
+
+	if found.Path == "" {
+		return types.CradleProject{}, fmt.Errorf("%s is not inside a cradle project, provide a project name", workDirPath)
+	}
+
+	return found, nil
+}
+
+// rememberedInputs returns the answers recorded when the template was last
+// applied to the project, limited to inputs the template still declares.
+func rememberedInputs(project types.CradleProject, templateData *cradleTemplate.TemplateData) map[string]string {
+	values := make(map[string]string)
+
+	applied, found := project.AppliedTemplate(templateData.Ref)
+	if !found {
+		return values
+	}
+
+	for _, input := range templateData.Inputs {
+		if value, exists := applied.Inputs[input.Name]; exists && !input.IsComputed() {
+			values[input.Name] = value
+		}
+	}
+
+	return values
+}
+
+// promptConflict asks what to do with a project file that differs from the template.
+func promptConflict(filePath string) (string, error) {
+	action := conflictSkip
+
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewSelect[string]().
+			Title(filePath+" differs from the template").
+			Options(
+				huh.NewOption("Keep the project file", conflictSkip),
+				huh.NewOption("Overwrite with the template", conflictOverwrite),
+				huh.NewOption("Write the template version to "+filePath+".new", conflictNew),
+			).
+			Value(&action),
+	)).Run()
+
+	return action, err
+}
+
+// writeProjectFile writes a rendered file, creating its parent directories.
+func writeProjectFile(filePath string, content []byte, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, content, mode)
+	if err != nil {
+		return err
+	}
+
+	// WriteFile keeps the permissions of an existing file.
+	return os.Chmod(filePath, mode)
+}
+
+var (
+	diffHeaderStyle = lipgloss.NewStyle().Bold(true).TabWidth(lipgloss.NoTabConversion)
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6")).TabWidth(lipgloss.NoTabConversion)
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).TabWidth(lipgloss.NoTabConversion)
+	diffInsertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).TabWidth(lipgloss.NoTabConversion)
+)
+
+// printFileDiff prints the changes to a project file as a colored unified
+// diff. oldContent is nil for a new file.
+func printFileDiff(filePath string, oldContent, newContent []byte) {
+	oldName := "a/" + filePath
+	if oldContent == nil {
+		oldName = "/dev/null"
+	}
+
+	if bytes.IndexByte(oldContent, 0) >= 0 || bytes.IndexByte(newContent, 0) >= 0 {
+		fmt.Println(diffHeaderStyle.Render("Binary file " + filePath + " differs"))
+		return
+	}
+
+	unified := diff.Unified(oldName, "b/"+filePath, string(oldContent), string(newContent))
+
+	for _, line := range strings.SplitAfter(unified, "\n") {
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			fmt.Println(diffHeaderStyle.Render(text))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(diffHunkStyle.Render(text))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(diffDeleteStyle.Render(text))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(diffInsertStyle.Render(text))
+		case text != "":
+			fmt.Println(text)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"embed"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+func TestApplyTemplateConflicts(t *testing.T) {
+	const template = `version: v1
+name: extras
+inputs:
+  - name: Tag
+files:
+  README.md: "# {{.ProjectName}} {{.Tag}}\n"
+  same.txt: "same\n"
+  new.txt: "new\n"
+`
+	projectFiles := map[string]string{
+		"README.md": "# edited\n",
+		"same.txt":  "same\n",
+	}
+
+	tests := []struct {
+		conflict string
+		// wantFiles is the content of the project files after applying, empty for missing files.
+		wantFiles map[string]string
+	}{
+		{
+			conflict: conflictSkip,
+			wantFiles: map[string]string{
+				"README.md":     "# edited\n",
+				"README.md.new": "",
+			},
+		},
+		{
+			conflict: conflictOverwrite,
+			wantFiles: map[string]string{
+				"README.md":     "# demo v1\n",
+				"README.md.new": "",
+			},
+		},
+		{
+			conflict: conflictNew,
+			wantFiles: map[string]string{
+				"README.md":     "# edited\n",
+				"README.md.new": "# demo v1\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.conflict, func(t *testing.T) {
+			homePath := t.TempDir()
+			t.Setenv(config.EnvCradleHome, homePath)
+
+			err := config.Init()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cradleTemplate.SetTemplateFS(embed.FS{})
+
+			templateDirPath := filepath.Join(homePath, config.CradleTemplatesDir)
+			err = os.MkdirAll(templateDirPath, 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(filepath.Join(templateDirPath, "extras.yaml"), []byte(template), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectPath := filepath.Join(t.TempDir(), "demo")
+			err = os.MkdirAll(projectPath, 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range projectFiles {
+				err = os.WriteFile(filepath.Join(projectPath, name), []byte(content), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = config.AddProject(types.CradleProject{Path: projectPath})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = applyTemplate(applyTemplateParams{
+				Template:    "extras",
+				Project:     projectPath,
+				InputValues: map[string]string{"Tag": "v1"},
+				NoInput:     true,
+				Conflict:    test.conflict,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantFiles := map[string]string{"same.txt": "same\n", "new.txt": "new\n"}
+			maps.Copy(wantFiles, test.wantFiles)
+
+			for name, want := range wantFiles {
+				content, err := os.ReadFile(filepath.Join(projectPath, name))
+				if want == "" {
+					if !errors.Is(err, os.ErrNotExist) {
+						t.Errorf("%s exists, want it not to be written", name)
+					}
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+					continue
+				}
+				if string(content) != want {
+					t.Errorf("%s = %q, want %q", name, content, want)
+				}
+			}
+
+			project, found := config.FindProject(projectPath)
+			if !found {
+				t.Fatalf("project %s is not registered", projectPath)
+			}
+			applied, found := project.AppliedTemplate("extras")
+			if !found {
+				t.Fatal("applied template extras was not recorded")
+			}
+			if applied.Inputs["Tag"] != "v1" {
+				t.Errorf("recorded inputs = %v, want Tag=v1", applied.Inputs)
+			}
+		})
+	}
+}
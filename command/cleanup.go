@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/gurleensethi/cradle/internal/types"
 	"github.com/urfave/cli/v3"
 )
 
@@ -14,16 +16,36 @@ func Cleanup() *cli.Command {
 	return &cli.Command{
 		Name:  "cleanup",
 		Usage: "Remove all temporary projects that were created by cradle. ",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "older-than",
+				Usage: "only remove temporary projects created longer than `age` ago, e.g. 12h or 7d, overrides the cleanup_ttl setting",
+			},
+		},
 		Action: func(ctx context.Context, c *cli.Command) error {
-			_, err := cleanupTemporaryProjects()
+			ttl := config.Get().Settings.CleanupTTL
+			if c.IsSet("older-than") {
+				var err error
+				ttl, err = config.ParseAge(c.String("older-than"))
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := cleanupTemporaryProjects(ttl)
 			return err
 		},
 	}
 }
 
-// cleanupTemporaryProjects removes all temporary projects after user confirmation. Returns the count of remaining permanent projects.
-func cleanupTemporaryProjects() (int, error) {
-	tempProjects := config.TemporaryProjects()
+// cleanupTemporaryProjects removes the temporary projects older than ttl, all of them for a zero ttl, after user confirmation. Returns the count of remaining projects.
+func cleanupTemporaryProjects(ttl time.Duration) (int, error) {
+	var tempProjects []types.CradleProject
+	for _, project := range config.TemporaryProjects() {
+		if time.Since(project.CreatedAt) >= ttl {
+			tempProjects = append(tempProjects, project)
+		}
+	}
 	count := len(tempProjects)
 
 	if count == 0 {
@@ -48,13 +70,16 @@ func cleanupTemporaryProjects() (int, error) {
 		}
 	}
 
-	// Update config with only permanent projects
-	permanentProjects := config.PermanentProjects()
-	if err := config.UpdateProjects(permanentProjects); err != nil {
+	// Remove the deleted projects from config
+	var removedPaths []string
+	for _, project := range tempProjects {
+		removedPaths = append(removedPaths, project.Path)
+	}
+	if err := config.RemoveProjects(removedPaths); err != nil {
 		return 0, err
 	}
 
 	fmt.Printf("Removed %d temporary projects.\n", count)
 
-	return len(permanentProjects), nil
+	return len(config.Projects()), nil
 }
@@ -0,0 +1,212 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/urfave/cli/v3"
+)
+
+// Config returns the config command group for reading and changing the settings.
+func Config() *cli.Command {
+	keyArg := &cli.StringArg{
+		Name:      "key",
+		UsageText: "key of the setting",
+		Config: cli.StringConfig{
+			TrimSpace: true,
+		},
+	}
+
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Read and change the settings in settings.yaml",
+		Commands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "List every setting with its value and where the value comes from",
+				Aliases: []string{"ls"},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return listSettings()
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "Print the value of a setting",
+				Arguments: []cli.Argument{keyArg},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					key := c.StringArg("key")
+					if key == "" {
+						return fmt.Errorf("provide a setting key")
+					}
+
+					setting, err := config.LookupSetting(key)
+					if err != nil {
+						return err
+					}
+
+					if setting.Source == "" {
+						fmt.Printf("%s is not set, the default is %s\n", key, setting.Default)
+						return nil
+					}
+
+					fmt.Println(setting.Value)
+					return nil
+				},
+			},
+			{
+				Name:  "set",
+				Usage: "Write the value of a setting to settings.yaml",
+				Arguments: []cli.Argument{
+					keyArg,
+					&cli.StringArg{
+						Name:      "value",
+						UsageText: "value of the setting",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					key := c.StringArg("key")
+					if key == "" {
+						return fmt.Errorf("provide a setting key")
+					}
+
+					err := config.SetSetting(key, c.StringArg("value"))
+					if err != nil {
+						return err
+					}
+
+					setting, err := config.LookupSetting(key)
+					if err != nil {
+						return err
+					}
+
+					fmt.Printf("Set %s in %s\n", key, config.Get().SettingsFilePath)
+					if setting.Source != config.SettingsFileName {
+						fmt.Printf("warning: %s is set, it overrides the value in %s\n", setting.Source, config.SettingsFileName)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "unset",
+				Usage:     "Remove a setting from settings.yaml, restoring its default",
+				Arguments: []cli.Argument{keyArg},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					key := c.StringArg("key")
+					if key == "" {
+						return fmt.Errorf("provide a setting key")
+					}
+
+					removed, err := config.UnsetSetting(key)
+					if err != nil {
+						return err
+					}
+
+					if !removed {
+						fmt.Printf("%s is not set in %s\n", key, config.Get().SettingsFilePath)
+						return nil
+					}
+
+					fmt.Printf("Removed %s from %s\n", key, config.Get().SettingsFilePath)
+					return nil
+				},
+			},
+			{
+				Name:  "edit",
+				Usage: "Open settings.yaml in the editor and check it once the editor exits",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return editSettings()
+				},
+			},
+		},
+	}
+}
+
+// listSettings prints every setting in a table.
+func listSettings() error {
+	settings, err := config.ListSettings()
+	if err != nil {
+		return err
+	}
+
+	unsetStyle := lipgloss.NewStyle().Faint(true)
+
+	rows := [][]string{}
+	for _, setting := range settings {
+		value := setting.Value
+		source := setting.Source
+		if source == "" {
+			value = unsetStyle.Render(setting.Default)
+			source = unsetStyle.Render("default")
+		}
+
+		rows = append(rows, []string{setting.Key, value, source, setting.Usage})
+	}
+
+	rowStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			return rowStyle
+		}).
+		Headers("Key", "Value", "Source", "Description").
+		Rows(rows...)
+
+	fmt.Println(t)
+	fmt.Println("Settings are read from", config.Get().SettingsFilePath+", environment variables CRADLE_<KEY> take precedence.")
+
+	return nil
+}
+
+// editSettings opens the settings file in the editor and reports the problems
+// found in it afterwards.
+func editSettings() error {
+	settingsFilePath, err := config.EnsureSettingsFile()
+	if err != nil {
+		return err
+	}
+
+	err = runEditor(settingsFilePath, "")
+	if err != nil {
+		return err
+	}
+
+	problems := config.CheckSettings()
+	for _, problem := range problems {
+		fmt.Println("warning:", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s has problems, run `cradle config edit` again to fix them", settingsFilePath)
+	}
+
+	return nil
+}
+
+// runEditor opens target with the editor setting, in dirPath unless it is
+// empty. The editor's error output goes to stdout, stderr is reserved for
+// the shell integration.
+func runEditor(target, dirPath string) error {
+	editor := strings.Fields(config.Get().Settings.Editor)
+	if len(editor) == 0 {
+		return fmt.Errorf("no editor set, run `cradle config set editor <command>`")
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], target)...)
+	cmd.Dir = dirPath
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stdout
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("run editor %s: %w", editor[0], err)
+	}
+
+	return nil
+}
@@ -1,20 +1,21 @@
 package command
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"maps"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/gurleensethi/cradle/internal/config"
 	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
 	"github.com/gurleensethi/cradle/internal/types"
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v3"
 )
 
@@ -33,24 +34,74 @@ func Create() *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:     "template",
-				Usage:    "specify a template to use for project creation",
+				Usage:    "specify a template to use for project creation, overrides the default_template setting",
 				Required: false,
 			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "set a template input as `Key=Value`, can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "values",
+				Usage: "read template inputs from a YAML or JSON `file`",
+			},
+			&cli.BoolFlag{
+				Name:  "no-input",
+				Usage: "never prompt for template inputs, use defaults for inputs that are not set",
+			},
+			&cli.BoolFlag{
+				Name:  "fresh",
+				Usage: "do not offer the answers given last time as defaults",
+			},
+			&cli.BoolFlag{
+				Name:  "no-hooks",
+				Usage: "do not run the template's post create hooks",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "show the files the template would create without creating the project",
+			},
+			&cli.BoolFlag{
+				Name:  "show-content",
+				Usage: "with --dry-run, also print every rendered file",
+			},
 		},
+		// Values passed with --set may contain commas.
+		DisableSliceFlagSeparator: true,
 		Action: func(ctx context.Context, c *cli.Command) error {
 			if c.Args().Len() == 0 {
 				return fmt.Errorf("provide a project name")
 			}
 
+			inputValues, err := readInputValues(c.String("values"), c.StringSlice("set"))
+			if err != nil {
+				return err
+			}
+
+			templateName := config.Get().Settings.DefaultTemplate
+			if c.IsSet("template") {
+				templateName = c.String("template")
+			}
+
 			newProjectPath, err := createProject(createProjectParams{
-				Name:     strings.Join(c.Args().Slice(), "-"),
-				Temp:     c.Bool("temp"),
-				Template: c.String("template"),
+				Name:        strings.Join(c.Args().Slice(), "-"),
+				Temp:        c.Bool("temp"),
+				Template:    templateName,
+				InputValues: inputValues,
+				NoInput:     c.Bool("no-input") || !isatty.IsTerminal(os.Stdin.Fd()),
+				Fresh:       c.Bool("fresh"),
+				NoHooks:     c.Bool("no-hooks"),
+				DryRun:      c.Bool("dry-run"),
+				ShowContent: c.Bool("show-content"),
 			})
 			if err != nil {
 				return err
 			}
 
+			if newProjectPath == "" {
+				return nil
+			}
+
 			if config.Get().CradleCommandOut {
 				fmt.Fprintf(os.Stderr, "eval cd %s", newProjectPath)
 			}
@@ -64,11 +115,27 @@ type createProjectParams struct {
 	Name     string
 	Temp     bool
 	Template string
+	// InputValues are template inputs provided upfront, they are not prompted for.
+	InputValues map[string]string
+	// NoInput disables prompting for template inputs.
+	NoInput bool
+	// Fresh ignores the answers remembered for the template.
+	Fresh bool
+	// NoHooks skips the template's post create hooks.
+	NoHooks bool
+	// DryRun renders the template and prints the result without creating or registering the project.
+	DryRun bool
+	// ShowContent prints the content of every rendered file in a dry run.
+	ShowContent bool
 }
 
-// createProject creates a project directory and registers it. Returns the created path.
+// createProject creates a project directory and registers it. Returns the
+// created path, or an empty path for a dry run.
+// The project is rendered into a staging directory first and only moved into
+// place and registered once every file was written, so a failure never leaves
+// a partially created project behind.
 func createProject(params createProjectParams) (string, error) {
-	newProjectPath := path.Join(config.Get().CradleHomeDirPath, params.Name)
+	newProjectPath := path.Join(config.Get().Settings.ProjectsDirPath, params.Name)
 
 	// Make sure there is no existing project with same name
 	for _, project := range config.Projects() {
@@ -77,11 +144,25 @@ func createProject(params createProjectParams) (string, error) {
 		}
 	}
 
-	files := make(map[string]string)
+	if _, err := os.Stat(newProjectPath); err == nil {
+		return "", fmt.Errorf("directory %s already exists", newProjectPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	var (
+		files         []cradleTemplate.RenderedFile
+		templateData  *cradleTemplate.TemplateData
+		templateInput map[string]any
+		// appliedTemplate records the template in the registry so the project can be upgraded later.
+		appliedTemplate *types.AppliedTemplate
+	)
 
 	// If a template is specified, use it to create the project
 	if params.Template != "" {
-		templateData, err := cradleTemplate.GetTemplate(params.Template)
+		var err error
+
+		templateData, err = cradleTemplate.GetTemplate(params.Template)
 		if err != nil {
 			if errors.Is(err, cradleTemplate.ErrNotExists) {
 				return "", fmt.Errorf("template %s does not exist", params.Template)
@@ -90,48 +171,73 @@ func createProject(params createProjectParams) (string, error) {
 			return "", err
 		}
 
-		userInputs, err := cradleTemplate.ReadUserInputs(templateData)
+		for _, warning := range templateData.Warnings {
+			fmt.Println("warning:", warning)
+		}
+
+		history, err := inputHistory(templateData, params.Fresh)
 		if err != nil {
 			return "", err
 		}
 
-		templateInput := map[string]string{
-			"ProjectName": params.Name,
+		generated := types.Generated{Time: time.Now()}
+
+		files, templateInput, err = renderProjectFiles(templateData, params.Name, cradleTemplate.InputOptions{
+			Values:    params.InputValues,
+			NoInput:   params.NoInput,
+			History:   history,
+			Generated: &generated,
+		})
+		if err != nil {
+			return "", err
 		}
 
-		maps.Copy(templateInput, userInputs)
+		appliedTemplate = &types.AppliedTemplate{
+			Name:      params.Template,
+			Inputs:    cradleTemplate.FormatInputs(templateData, templateInput),
+			AppliedAt: time.Now(),
+			Generated: generated,
+		}
+	}
 
-		for filePath, templateFile := range templateData.Files {
-			buffer := bytes.NewBuffer([]byte{})
+	if params.DryRun {
+		fmt.Printf("Dry run, %s would be created with:\n", newProjectPath)
+		printRenderedFiles(files, params.ShowContent)
 
-			t, err := template.New("").Parse(string(templateFile))
-			if err != nil {
-				// TODO: add better error message, denoting which file failed
-				// TODO: rendering and why.
-				return "", err
+		if templateData != nil && len(templateData.Hooks.PostCreate) > 0 && !params.NoHooks {
+			fmt.Println("Post create hooks:")
+			for _, hook := range templateData.Hooks.PostCreate {
+				fmt.Println("  " + hook.Run)
 			}
+		}
 
-			err = t.Execute(buffer, templateInput)
-			if err != nil {
-				return "", err
-			}
+		return "", nil
+	}
 
-			files[filePath] = buffer.String()
+	// The version is only stored once the project is registered, so a failed
+	// create leaves nothing behind.
+	if appliedTemplate != nil {
+		version, err := cradleTemplate.Version(templateData)
+		if err != nil {
+			return "", err
 		}
+		appliedTemplate.Version = version
 	}
 
-	// Create the project directory
-	err := os.Mkdir(newProjectPath, os.ModePerm)
+	err := os.MkdirAll(path.Dir(newProjectPath), os.ModePerm)
 	if err != nil {
 		return "", err
 	}
 
-	for filePath, fileContent := range files {
-		err := os.WriteFile(path.Join(newProjectPath, filePath), []byte(fileContent), 0o644)
-		if err != nil {
-			// TODO: return better error message, denoting which file failed to be created.
-			return "", err
-		}
+	stagingPath, err := stageProject(path.Dir(newProjectPath), path.Base(newProjectPath), files)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(stagingPath, newProjectPath)
+	if err != nil {
+		_ = os.RemoveAll(stagingPath)
+		return "", fmt.Errorf("move project into place: %w", err)
 	}
 
 	cradleProject := types.CradleProject{
@@ -141,5 +247,185 @@ func createProject(params createProjectParams) (string, error) {
 		CreatedBy: "cradle",
 	}
 
-	return newProjectPath, config.AddProject(cradleProject)
+	if appliedTemplate != nil {
+		cradleProject.Template = appliedTemplate.Name
+		cradleProject.SetAppliedTemplate(*appliedTemplate)
+	}
+
+	err = config.AddProject(cradleProject)
+	if err != nil {
+		_ = os.RemoveAll(newProjectPath)
+		return "", fmt.Errorf("register project: %w", err)
+	}
+
+	if templateData != nil && !params.NoInput {
+		rememberInputs(templateData, templateInput)
+	}
+
+	if appliedTemplate != nil {
+		_, err = cradleTemplate.SaveVersion(templateData)
+		if err != nil {
+			return "", fmt.Errorf("project created at %s, but the template version to upgrade it from was not saved: %w", newProjectPath, err)
+		}
+	}
+
+	if templateData != nil && len(templateData.Hooks.PostCreate) > 0 && !params.NoHooks {
+		err = runPostCreateHooks(templateData, newProjectPath, templateInput, params.NoInput)
+		if err != nil {
+			return "", fmt.Errorf("project created at %s, but %w", newProjectPath, err)
+		}
+	}
+
+	return newProjectPath, nil
+}
+
+// renderProjectFiles reads the template inputs and renders the template for
+// a project named projectName. It returns the rendered files and the data
+// they were rendered with: ProjectName and the typed inputs. opts.Generated
+// pins now, year and uuid for the files too.
+func renderProjectFiles(templateData *cradleTemplate.TemplateData, projectName string, opts cradleTemplate.InputOptions) ([]cradleTemplate.RenderedFile, map[string]any, error) {
+	templateInput := map[string]any{
+		"ProjectName": projectName,
+	}
+	opts.Builtins = templateInput
+
+	userInputs, err := cradleTemplate.ReadInputs(templateData, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maps.Copy(templateInput, userInputs)
+
+	files, err := cradleTemplate.Render(templateData, templateInput, opts.Generated)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, templateInput, nil
+}
+
+// inputHistory returns the answers remembered for the template, none when fresh is set.
+func inputHistory(templateData *cradleTemplate.TemplateData, fresh bool) (map[string]string, error) {
+	if fresh {
+		return nil, nil
+	}
+	return cradleTemplate.History(templateData.Ref)
+}
+
+// rememberInputs records the answers to the template inputs to offer them
+// next time. Failing to do so does not fail the command.
+func rememberInputs(templateData *cradleTemplate.TemplateData, inputs map[string]any) {
+	err := cradleTemplate.RememberInputs(templateData, inputs)
+	if err != nil {
+		fmt.Println("warning: remember answers:", err)
+	}
+}
+
+// runPostCreateHooks runs the template's post create hooks in the project
+// directory. Hooks of user templates only run after the user confirmed them
+// once; without a terminal to ask, untrusted hooks are skipped.
+func runPostCreateHooks(templateData *cradleTemplate.TemplateData, projectPath string, inputs map[string]any, noInput bool) error {
+	untrusted, err := cradleTemplate.UntrustedHooks(templateData)
+	if err != nil {
+		return err
+	}
+
+	if len(untrusted) > 0 {
+		if noInput {
+			var refs []string
+			for _, part := range untrusted {
+				refs = append(refs, part.Ref)
+			}
+			fmt.Printf("Skipped post create hooks of untrusted template %s, run create interactively once to allow them.\n", strings.Join(refs, ", "))
+			return nil
+		}
+
+		for _, part := range untrusted {
+			fmt.Printf("Template %s (%s) wants to run these commands in %s:\n", part.Ref, part.Source, projectPath)
+			for _, hook := range part.Hooks.PostCreate {
+				fmt.Println("  " + hook.Run)
+			}
+		}
+
+		var confirmation string
+		fmt.Print("Allow these templates to run commands (Y/N):")
+		fmt.Scanln(&confirmation)
+
+		if confirmation != "Y" && confirmation != "y" {
+			fmt.Println("Skipped post create hooks.")
+			return nil
+		}
+
+		err = cradleTemplate.TrustHooks(untrusted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cradleTemplate.RunHooks(templateData.Hooks.PostCreate, projectPath, inputs)
+}
+
+// stageProject writes the files into a new hidden staging directory inside
+// parentDirPath and returns its path. The staging directory is removed if any
+// file fails to be written.
+func stageProject(parentDirPath, name string, files []cradleTemplate.RenderedFile) (_ string, err error) {
+	stagingPath, err := os.MkdirTemp(parentDirPath, ".cradle-staging-"+name+"-")
+	if err != nil {
+		return "", fmt.Errorf("create staging directory: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(stagingPath)
+		}
+	}()
+
+	// MkdirTemp creates the directory accessible only by the owner.
+	err = os.Chmod(stagingPath, 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		filePath := filepath.Join(stagingPath, filepath.FromSlash(file.Path))
+
+		err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+		if err == nil {
+			err = os.WriteFile(filePath, file.Content, file.Mode)
+		}
+		if err != nil {
+			// Report the cause without the staging directory path, which is meaningless to the user.
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) {
+				err = pathErr.Err
+			}
+			return "", fmt.Errorf("write file %s: %w", file.Path, err)
+		}
+	}
+
+	return stagingPath, nil
+}
+
+// readInputValues merges template inputs from a values file with `Key=Value`
+// assignments, assignments take precedence over the file.
+func readInputValues(valuesFilePath string, assignments []string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if valuesFilePath != "" {
+		fileValues, err := cradleTemplate.ReadValuesFile(valuesFilePath)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(values, fileValues)
+	}
+
+	for _, assignment := range assignments {
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --set %q, expected Key=Value", assignment)
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+
+	return values, nil
 }
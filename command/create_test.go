@@ -0,0 +1,118 @@
+package command
+
+import (
+	"embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+)
+
+// TestCreateProjectFailureLeavesNothingBehind creates a project whose files
+// cannot be written, which must leave neither the project nor its staging
+// directory behind, and register and save nothing.
+func TestCreateProjectFailureLeavesNothingBehind(t *testing.T) {
+	homePath := t.TempDir()
+	t.Setenv(config.EnvCradleHome, homePath)
+
+	err := config.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cradleTemplate.SetTemplateFS(embed.FS{})
+
+	templateDirPath := filepath.Join(homePath, config.CradleTemplatesDir)
+	err = os.MkdirAll(templateDirPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// docs is both a file and a directory, so the project cannot be written.
+	template := "version: v1\nname: broken\nfiles:\n  docs: \"file\\n\"\n  docs/readme.md: \"nested\\n\"\n"
+	err = os.WriteFile(filepath.Join(templateDirPath, "broken.yaml"), []byte(template), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = createProject(createProjectParams{Name: "demo", Template: "broken", NoInput: true})
+	if err == nil {
+		t.Fatal("createProject() succeeded, want an error")
+	}
+
+	entries, err := os.ReadDir(homePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() == "demo" || strings.HasPrefix(entry.Name(), ".cradle-staging-") {
+			t.Errorf("%s was left behind", entry.Name())
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(config.Get().StateDirPath, "template_versions")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("template versions were saved for a project that was not created: %v", err)
+	}
+	if len(config.Projects()) != 0 {
+		t.Errorf("projects = %v, want none", config.Projects())
+	}
+}
+
+func TestCreateProjectNestedFilesAndModes(t *testing.T) {
+	homePath := t.TempDir()
+	t.Setenv(config.EnvCradleHome, homePath)
+
+	err := config.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cradleTemplate.SetTemplateFS(embed.FS{})
+
+	templateDirPath := filepath.Join(homePath, config.CradleTemplatesDir)
+	err = os.MkdirAll(templateDirPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := `version: v1
+name: nested
+files:
+  README.md: "# readme\n"
+  cmd/api/main.go: "package main\n"
+  scripts/run.sh:
+    content: "#!/bin/sh\n"
+    executable: true
+  config/secret.env:
+    content: "TOKEN=\n"
+    mode: 0600
+`
+	err = os.WriteFile(filepath.Join(templateDirPath, "nested.yaml"), []byte(template), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectPath, err := createProject(createProjectParams{Name: "demo", Template: "nested", NoInput: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantModes := map[string]os.FileMode{
+		"README.md":         0o644,
+		"cmd/api/main.go":   0o644,
+		"scripts/run.sh":    0o755,
+		"config/secret.env": 0o600,
+	}
+	for name, want := range wantModes {
+		info, err := os.Stat(filepath.Join(projectPath, filepath.FromSlash(name)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package command
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/gurleensethi/cradle/internal/types"
 	"github.com/urfave/cli/v3"
 )
 
@@ -16,14 +18,29 @@ func List() *cli.Command {
 		Name:    "list",
 		Usage:   "List all projects managed by cradle",
 		Aliases: []string{"ls"},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "columns",
+				Usage: "comma separated `columns` to show (" + strings.Join(config.ListColumnNames, ", ") + "), overrides the list_columns setting",
+			},
+		},
 		Action: func(ctx context.Context, c *cli.Command) error {
-			return listProjects(ctx, c)
+			columns := config.Get().Settings.ListColumns
+			if c.IsSet("columns") {
+				var err error
+				columns, err = config.ParseListColumns(c.String("columns"))
+				if err != nil {
+					return err
+				}
+			}
+
+			return listProjects(columns)
 		},
 	}
 }
 
-// listProjects displays all registered projects in a table.
-func listProjects(ctx context.Context, c *cli.Command) error {
+// listProjects displays all registered projects in a table with the columns.
+func listProjects(columns []string) error {
 	projects := config.Projects()
 	if len(projects) == 0 {
 		fmt.Println("No projects found")
@@ -32,19 +49,17 @@ func listProjects(ctx context.Context, c *cli.Command) error {
 
 	rows := [][]string{}
 	for _, project := range projects {
-		var temp string
-		if project.Temporary {
-			temp = "Yes"
-		} else {
-			temp = "No"
+		var row []string
+		for _, column := range columns {
+			row = append(row, projectColumn(project, column))
 		}
 
-		rows = append(rows, []string{
-			project.UniqueNameFromPath,
-			project.Path,
-			temp,
-			project.CreatedAt.Format("2006-01-02 15:04:05"),
-		})
+		rows = append(rows, row)
+	}
+
+	var headers []string
+	for _, column := range columns {
+		headers = append(headers, listColumnHeaders[column])
 	}
 
 	rowStyle := lipgloss.NewStyle().Padding(0, 1)
@@ -54,10 +69,38 @@ func listProjects(ctx context.Context, c *cli.Command) error {
 		StyleFunc(func(row, col int) lipgloss.Style {
 			return rowStyle
 		}).
-		Headers("Name", "Path", "Temporary", "Time").
+		Headers(headers...).
 		Rows(rows...)
 
 	fmt.Println(t)
 
 	return nil
 }
+
+var listColumnHeaders = map[string]string{
+	"name":      "Name",
+	"path":      "Path",
+	"temporary": "Temporary",
+	"created":   "Time",
+	"template":  "Template",
+}
+
+// projectColumn returns the value of a list column for the project.
+func projectColumn(project types.CradleProject, column string) string {
+	switch column {
+	case "name":
+		return project.UniqueNameFromPath
+	case "path":
+		return project.Path
+	case "temporary":
+		if project.Temporary {
+			return "Yes"
+		}
+		return "No"
+	case "created":
+		return project.CreatedAt.Format("2006-01-02 15:04:05")
+	case "template":
+		return project.Template
+	}
+	return ""
+}
@@ -24,6 +24,13 @@ func Open() *cli.Command {
 				},
 			},
 		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "edit",
+				Aliases: []string{"e"},
+				Usage:   "also open the project in the editor setting",
+			},
+		},
 		Action: func(ctx context.Context, c *cli.Command) error {
 			name := c.StringArg("name")
 			if name == "" {
@@ -35,6 +42,13 @@ func Open() *cli.Command {
 				return fmt.Errorf("%s project not found", name)
 			}
 
+			if c.Bool("edit") {
+				err := runEditor(project.Path, project.Path)
+				if err != nil {
+					return err
+				}
+			}
+
 			if config.Get().CradleCommandOut {
 				fmt.Fprintf(os.Stderr, "eval cd %s", project.Path)
 			}
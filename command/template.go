@@ -0,0 +1,313 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/urfave/cli/v3"
+)
+
+// Template returns the template command group for inspecting, rendering, testing, saving and upgrading templates.
+func Template() *cli.Command {
+	return &cli.Command{
+		Name:  "template",
+		Usage: "Inspect, render, test, save and upgrade project templates",
+		Commands: []*cli.Command{
+			{
+				Name:    "list",
+				Usage:   "List all available templates",
+				Aliases: []string{"ls"},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return listTemplates()
+				},
+			},
+			{
+				Name:  "show",
+				Usage: "Show the inputs and files of a template",
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name:      "name",
+						UsageText: "name of the template to show",
+						Config: cli.StringConfig{
+							TrimSpace: true,
+						},
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					name := c.StringArg("name")
+					if name == "" {
+						return fmt.Errorf("provide a template name")
+					}
+
+					return showTemplate(name)
+				},
+			},
+			{
+				Name:  "lint",
+				Usage: "Check a template file or directory for problems",
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name:      "file",
+						UsageText: "path to the template file or directory",
+						Config: cli.StringConfig{
+							TrimSpace: true,
+						},
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					filePath := c.StringArg("file")
+					if filePath == "" {
+						return fmt.Errorf("provide a template file")
+					}
+
+					return lintTemplate(filePath)
+				},
+			},
+			templateRender(),
+			templateSave(),
+			templateUpgrade(),
+			templateTest(),
+			templateForget(),
+			templateAdd(),
+			templateUpdate(),
+			templateRemove(),
+		},
+	}
+}
+
+// listTemplates displays all available templates in a table.
+func listTemplates() error {
+	entries, err := cradleTemplate.ListTemplates()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No templates found")
+		return nil
+	}
+
+	rows := [][]string{}
+	for _, entry := range entries {
+		description := ""
+		if entry.Err != nil {
+			description = "invalid template: " + entry.Err.Error()
+		} else {
+			description = entry.Template.Description
+		}
+
+		rows = append(rows, []string{
+			entry.Name,
+			description,
+			string(entry.Source.Kind),
+		})
+	}
+
+	rowStyle := lipgloss.NewStyle().Padding(0, 1)
+
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			return rowStyle
+		}).
+		Headers("Name", "Description", "Source").
+		Rows(rows...)
+
+	fmt.Println(t)
+
+	return nil
+}
+
+// showTemplate prints the inputs of a template and the files it produces.
+func showTemplate(name string) error {
+	templateData, err := cradleTemplate.GetTemplate(name)
+	if err != nil {
+		if errors.Is(err, cradleTemplate.ErrNotExists) {
+			return fmt.Errorf("template %s does not exist", name)
+		}
+
+		return err
+	}
+
+	for _, warning := range templateData.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	headingStyle := lipgloss.NewStyle().Bold(true)
+
+	fmt.Println(headingStyle.Render(templateData.Name))
+	if templateData.Description != "" {
+		fmt.Println(templateData.Description)
+	}
+	fmt.Println("Source:", templateData.Source)
+	if templateData.Extends != "" {
+		fmt.Println("Extends:", templateData.Extends)
+	}
+	if len(templateData.Include) > 0 {
+		fmt.Println("Includes:", strings.Join(templateData.Include, ", "))
+	}
+
+	fmt.Println()
+	fmt.Println(headingStyle.Render("Inputs"))
+	if len(templateData.Inputs) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, input := range templateData.Inputs {
+		line := fmt.Sprintf("  %s (%s)", input.Name, input.Kind())
+		if input.Required {
+			line += " required"
+		}
+		if input.IsComputed() {
+			line += " computed"
+		}
+		if input.From != templateData.Ref {
+			line += " from " + input.From
+		}
+		fmt.Println(line)
+
+		if input.Description != "" {
+			fmt.Println("      " + input.Description)
+		}
+		if len(input.Options) > 0 {
+			fmt.Println("      options: " + strings.Join(input.Options, ", "))
+		}
+		if input.Default != "" && !input.IsSecret() {
+			fmt.Println("      default: " + input.Default)
+		}
+		if input.AskIf != "" {
+			fmt.Println("      ask if: " + input.AskIf)
+		}
+		if input.IsComputed() {
+			fmt.Println("      computed: " + input.Computed)
+		}
+		if rules := describeValidation(input.Validate); rules != "" {
+			fmt.Println("      validate: " + rules)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(headingStyle.Render("Files"))
+	printFileTree(slices.Sorted(maps.Keys(templateData.Files)), func(filePath string) string {
+		file := templateData.Files[filePath]
+
+		var notes []string
+		if file.From != templateData.Ref {
+			notes = append(notes, "from "+file.From)
+		}
+		if file.When != "" {
+			notes = append(notes, "when "+file.When)
+		}
+		if file.Verbatim || file.Encoding != "" {
+			notes = append(notes, "copied verbatim")
+		}
+		if len(notes) == 0 {
+			return ""
+		}
+		return "(" + strings.Join(notes, ", ") + ")"
+	})
+
+	if len(templateData.Hooks.PostCreate) > 0 {
+		fmt.Println()
+		fmt.Println(headingStyle.Render("Post create hooks"))
+		for _, hook := range templateData.Hooks.PostCreate {
+			line := "  " + hook.Run
+			if hook.Dir != "" {
+				line += " (in " + hook.Dir + ")"
+			}
+			if hook.When != "" {
+				line += " (when " + hook.When + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(headingStyle.Render("Functions"))
+	for _, templateFunc := range cradleTemplate.Funcs() {
+		fmt.Printf("  %-28s %s\n", templateFunc.Usage, templateFunc.Description)
+	}
+
+	return nil
+}
+
+// describeValidation returns a one line summary of the validation rules.
+func describeValidation(validation cradleTemplate.TemplateInputValidation) string {
+	var rules []string
+
+	if validation.Pattern != "" {
+		rules = append(rules, fmt.Sprintf("pattern %q", validation.Pattern))
+	}
+	if validation.MinLen != nil {
+		rules = append(rules, fmt.Sprintf("min_len %d", *validation.MinLen))
+	}
+	if validation.MaxLen != nil {
+		rules = append(rules, fmt.Sprintf("max_len %d", *validation.MaxLen))
+	}
+	if validation.Min != nil {
+		rules = append(rules, fmt.Sprintf("min %v", *validation.Min))
+	}
+	if validation.Max != nil {
+		rules = append(rules, fmt.Sprintf("max %v", *validation.Max))
+	}
+
+	return strings.Join(rules, ", ")
+}
+
+// printFileTree prints sorted slash separated file paths as an indented tree.
+// The note returned by annotate, if any, is printed next to each file.
+func printFileTree(filePaths []string, annotate func(filePath string) string) {
+	var previous []string
+
+	for _, filePath := range filePaths {
+		parts := strings.Split(filePath, "/")
+
+		common := 0
+		for common < len(parts)-1 && common < len(previous)-1 && parts[common] == previous[common] {
+			common++
+		}
+
+		for depth := common; depth < len(parts); depth++ {
+			name := parts[depth]
+			if depth < len(parts)-1 {
+				name += "/"
+			} else if note := annotate(filePath); note != "" {
+				name += " " + note
+			}
+			fmt.Println(strings.Repeat("  ", depth+1) + name)
+		}
+
+		previous = parts
+	}
+}
+
+// lintTemplate reports all problems found in a template file.
+func lintTemplate(filePath string) error {
+	templateData, err := cradleTemplate.LoadTemplateFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range templateData.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	issues := cradleTemplate.Lint(templateData)
+	if len(issues) == 0 {
+		fmt.Println("No issues found ✓")
+		return nil
+	}
+
+	fmt.Printf("Found %d issues:\n", len(issues))
+	for _, issue := range issues {
+		fmt.Println("- " + issue)
+	}
+
+	return fmt.Errorf("template %s has %d issues", filePath, len(issues))
+}
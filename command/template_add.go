@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/urfave/cli/v3"
+)
+
+// templateAdd returns the command adding a template registry.
+func templateAdd() *cli.Command {
+	return &cli.Command{
+		Name:  "add",
+		Usage: "Add a shared folder or local git repository listing templates in " + cradleTemplate.RegistryIndexFileName,
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "source",
+				UsageText: "path to the registry folder",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			source := c.StringArg("source")
+			if source == "" {
+				return fmt.Errorf("provide the path of a registry")
+			}
+
+			registry, err := cradleTemplate.AddRegistry(source)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Added registry %s\n", registry.Path)
+			printRegistryTemplates(registry)
+
+			return nil
+		},
+	}
+}
+
+// printRegistryTemplates lists the cached templates of a registry with their versions, latest first.
+func printRegistryTemplates(registry cradleTemplate.Registry) {
+	if len(registry.Templates) == 0 {
+		fmt.Println("  (no templates)")
+	}
+	for _, cached := range registry.Templates {
+		fmt.Printf("  %s %s\n", cached.Name, strings.Join(cached.Versions, ", "))
+	}
+}
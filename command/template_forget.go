@@ -0,0 +1,45 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/urfave/cli/v3"
+)
+
+// templateForget returns the command clearing the answers remembered for a template.
+func templateForget() *cli.Command {
+	return &cli.Command{
+		Name:  "forget",
+		Usage: "Forget the answers remembered for a template",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "name",
+				UsageText: "name of the template",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			name := c.StringArg("name")
+			if name == "" {
+				return fmt.Errorf("provide a template name")
+			}
+
+			forgotten, err := cradleTemplate.ForgetHistory(name)
+			if err != nil {
+				return err
+			}
+
+			if !forgotten {
+				fmt.Printf("No answers remembered for template %s\n", name)
+				return nil
+			}
+
+			fmt.Printf("Forgot the answers remembered for template %s\n", name)
+			return nil
+		},
+	}
+}
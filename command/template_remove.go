@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/urfave/cli/v3"
+)
+
+// templateRemove returns the command removing a template registry.
+func templateRemove() *cli.Command {
+	return &cli.Command{
+		Name:  "remove",
+		Usage: "Remove a registry and its cached templates",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "source",
+				UsageText: "path to the registry folder",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			source := c.StringArg("source")
+			if source == "" {
+				return fmt.Errorf("provide the path of a registry")
+			}
+
+			registry, err := cradleTemplate.RemoveRegistry(source)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed registry %s and %d cached templates\n", registry.Path, len(registry.Templates))
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,192 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v3"
+)
+
+// templateRender returns the command rendering a template without creating a project.
+func templateRender() *cli.Command {
+	return &cli.Command{
+		Name:  "render",
+		Usage: "Render a template to preview the files it creates",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "name",
+				UsageText: "name of the template to render",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "write the files into a new `dir`, or print them all with -, instead of listing them",
+			},
+			&cli.StringFlag{
+				Name:  "project-name",
+				Usage: "value of {{.ProjectName}}",
+				Value: "my-project",
+			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "set a template input as `Key=Value`, can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "values",
+				Usage: "read template inputs from a YAML or JSON `file`",
+			},
+			&cli.BoolFlag{
+				Name:  "no-input",
+				Usage: "never prompt for template inputs, use defaults for inputs that are not set",
+			},
+		},
+		// Values passed with --set may contain commas.
+		DisableSliceFlagSeparator: true,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			name := c.StringArg("name")
+			if name == "" {
+				return fmt.Errorf("provide a template name")
+			}
+
+			inputValues, err := readInputValues(c.String("values"), c.StringSlice("set"))
+			if err != nil {
+				return err
+			}
+
+			return renderTemplate(renderTemplateParams{
+				Template:    name,
+				ProjectName: c.String("project-name"),
+				Out:         c.String("out"),
+				InputValues: inputValues,
+				NoInput:     c.Bool("no-input") || !isatty.IsTerminal(os.Stdin.Fd()),
+			})
+		},
+	}
+}
+
+type renderTemplateParams struct {
+	Template    string
+	ProjectName string
+	// Out is a directory to write the files into, "-" for stdout, or empty to list the files.
+	Out         string
+	InputValues map[string]string
+	NoInput     bool
+}
+
+// renderTemplate renders a template with the full input pipeline, without
+// registering a project or writing anything to the cradle directories.
+func renderTemplate(params renderTemplateParams) error {
+	templateData, err := cradleTemplate.GetTemplate(params.Template)
+	if err != nil {
+		if errors.Is(err, cradleTemplate.ErrNotExists) {
+			return fmt.Errorf("template %s does not exist", params.Template)
+		}
+		return err
+	}
+
+	for _, warning := range templateData.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	files, _, err := renderProjectFiles(templateData, params.ProjectName, cradleTemplate.InputOptions{
+		Values:  params.InputValues,
+		NoInput: params.NoInput,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch params.Out {
+	case "":
+		printRenderedFiles(files, false)
+		return nil
+
+	case "-":
+		for i, file := range files {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("==> %s <==\n", file.Path)
+			os.Stdout.Write(file.Content)
+		}
+		return nil
+	}
+
+	dirEntries, err := os.ReadDir(params.Out)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(dirEntries) > 0 {
+		return fmt.Errorf("%s is not empty", params.Out)
+	}
+
+	for _, file := range files {
+		err = writeProjectFile(filepath.Join(params.Out, filepath.FromSlash(file.Path)), file.Content, file.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Rendered %d files into %s\n", len(files), params.Out)
+
+	return nil
+}
+
+// printRenderedFiles prints the rendered files as a tree with their sizes,
+// followed by the content of every file when showContent is set.
+func printRenderedFiles(files []cradleTemplate.RenderedFile, showContent bool) {
+	if len(files) == 0 {
+		fmt.Println("  (no files)")
+		return
+	}
+
+	filesByPath := make(map[string]cradleTemplate.RenderedFile, len(files))
+	for _, file := range files {
+		filesByPath[file.Path] = file
+	}
+
+	printFileTree(slices.Sorted(maps.Keys(filesByPath)), func(filePath string) string {
+		file := filesByPath[filePath]
+		return fmt.Sprintf("(%s, %s)", formatSize(len(file.Content)), file.Mode)
+	})
+
+	if !showContent {
+		return
+	}
+
+	headingStyle := lipgloss.NewStyle().Bold(true)
+	for _, file := range files {
+		fmt.Println()
+		fmt.Println(headingStyle.Render("==> " + file.Path + " <=="))
+		os.Stdout.Write(file.Content)
+	}
+}
+
+// formatSize returns a byte count in a human readable unit.
+func formatSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / 1024
+	for _, unit := range []string{"KiB", "MiB"} {
+		if value < 1024 {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+		value /= 1024
+	}
+
+	return fmt.Sprintf("%.1f GiB", value)
+}
@@ -0,0 +1,283 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/gurleensethi/cradle/internal/config"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// templateSave returns the command turning a registered project into a user template.
+func templateSave() *cli.Command {
+	return &cli.Command{
+		Name:  "save",
+		Usage: "Save a project as a template",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "project",
+				UsageText: "name or path of the project to save",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "name",
+				Usage:    "`name` of the new template",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "description",
+				Usage: "description of the new template",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "leave out files matching a .gitignore style `pattern`, can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "binary",
+				Usage: "what to do with binary files, `skip` or encode",
+				Value: "skip",
+			},
+			&cli.StringSliceFlag{
+				Name:  "var",
+				Usage: "replace a literal with an input as `Input=literal`, can be repeated",
+			},
+			&cli.BoolFlag{
+				Name:  "no-input",
+				Usage: "do not prompt for literals to turn into inputs",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "overwrite an existing user template with the same name",
+			},
+		},
+		// Literals passed with --var may contain commas.
+		DisableSliceFlagSeparator: true,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			projectQuery := c.StringArg("project")
+			if projectQuery == "" {
+				return fmt.Errorf("provide a project name")
+			}
+
+			binary := c.String("binary")
+			if binary != "skip" && binary != "encode" {
+				return fmt.Errorf("invalid --binary %q, expected skip or encode", binary)
+			}
+
+			variables, err := parseSnapshotVariables(c.StringSlice("var"))
+			if err != nil {
+				return err
+			}
+
+			return saveTemplate(saveTemplateParams{
+				Project:      projectQuery,
+				Name:         strings.TrimSpace(c.String("name")),
+				Description:  c.String("description"),
+				Exclude:      c.StringSlice("exclude"),
+				EncodeBinary: binary == "encode",
+				Variables:    variables,
+				NoInput:      c.Bool("no-input") || !isatty.IsTerminal(os.Stdin.Fd()),
+				Force:        c.Bool("force"),
+			})
+		},
+	}
+}
+
+type saveTemplateParams struct {
+	Project      string
+	Name         string
+	Description  string
+	Exclude      []string
+	EncodeBinary bool
+	// Variables are literals to replace given upfront, they are used instead of prompting.
+	Variables []cradleTemplate.SnapshotVariable
+	NoInput   bool
+	Force     bool
+}
+
+// saveTemplate snapshots a project and writes it to the user template directory.
+func saveTemplate(params saveTemplateParams) error {
+	if params.Name == "" || strings.ContainsAny(params.Name, `/\`) {
+		return fmt.Errorf("invalid template name %q", params.Name)
+	}
+
+	project, err := openProject(params.Project)
+	if err != nil {
+		return err
+	}
+
+	templateDirPath := filepath.Join(config.Get().ConfigDirPath, config.CradleTemplatesDir)
+	templateFilePath := filepath.Join(templateDirPath, params.Name+".yaml")
+	// A template of the same name may also be a directory template.
+	existingDirPath := filepath.Join(templateDirPath, params.Name)
+
+	for _, existingPath := range []string{templateFilePath, existingDirPath} {
+		if _, err := os.Stat(existingPath); err == nil && !params.Force {
+			return fmt.Errorf("template %s already exists, use --force to overwrite it", existingPath)
+		}
+	}
+
+	variables := params.Variables
+	if len(variables) == 0 && !params.NoInput {
+		variables, err = selectSnapshotVariables(cradleTemplate.SnapshotCandidates(project.Path))
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := cradleTemplate.Snapshot(project.Path, cradleTemplate.SnapshotOptions{
+		Name:         params.Name,
+		Description:  params.Description,
+		Exclude:      params.Exclude,
+		EncodeBinary: params.EncodeBinary,
+		Variables:    variables,
+	})
+	if err != nil {
+		return fmt.Errorf("snapshot project %s: %w", project.Path, err)
+	}
+
+	if len(result.Template.Files) == 0 {
+		return fmt.Errorf("project %s has no files to save", project.Path)
+	}
+
+	if len(result.Replacements) > 0 {
+		printSnapshotReplacements(result.Replacements)
+
+		if !params.NoInput {
+			fmt.Print("Save the template with these replacements? (Y/N):")
+
+			var confirmation string
+			fmt.Scanln(&confirmation)
+
+			if confirmation != "Y" && confirmation != "y" {
+				fmt.Println("Template not saved, pick the replacements with --var Input=literal")
+				return nil
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	yamlEncoder := yaml.NewEncoder(&buf)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(result.Template); err != nil {
+		return err
+	}
+	if err := yamlEncoder.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(templateDirPath, 0o755); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(templateFilePath, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	// The directory template is replaced, a template cannot be defined both ways.
+	if err := os.RemoveAll(existingDirPath); err != nil {
+		return err
+	}
+
+	for _, skipped := range result.Skipped {
+		fmt.Println("skipped", skipped)
+	}
+
+	fmt.Printf("Saved template %s with %d files to %s\n", params.Name, len(result.Template.Files), templateFilePath)
+
+	return nil
+}
+
+// printSnapshotReplacements lists where literals were replaced with
+// placeholders, one line per file and literal.
+func printSnapshotReplacements(replacements []cradleTemplate.SnapshotReplacement) {
+	type group struct {
+		file     string
+		variable cradleTemplate.SnapshotVariable
+	}
+
+	var groups []group
+	lines := make(map[group][]string)
+
+	for _, replacement := range replacements {
+		key := group{replacement.File, replacement.Variable}
+		if _, exists := lines[key]; !exists {
+			groups = append(groups, key)
+		}
+
+		line := "path"
+		if replacement.Line > 0 {
+			line = strconv.Itoa(replacement.Line)
+		}
+		lines[key] = append(lines[key], line)
+	}
+
+	fmt.Println("Replaced literals with inputs:")
+	for _, key := range groups {
+		fmt.Printf("  %s (%s): %q as {{.%s}}\n", key.file, strings.Join(lines[key], ", "), key.variable.Literal, key.variable.Input)
+	}
+}
+
+// selectSnapshotVariables asks which of the candidate literals become inputs.
+func selectSnapshotVariables(candidates []cradleTemplate.SnapshotVariable) ([]cradleTemplate.SnapshotVariable, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	options := make([]huh.Option[int], len(candidates))
+	selected := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		options[i] = huh.NewOption(fmt.Sprintf("%q as {{.%s}}", candidate.Literal, candidate.Input), i).Selected(true)
+		selected[i] = i
+	}
+
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[int]().
+			Title("Turn these literals into template inputs?").
+			Options(options...).
+			Value(&selected),
+	)).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	var variables []cradleTemplate.SnapshotVariable
+	for i, candidate := range candidates {
+		if slices.Contains(selected, i) {
+			variables = append(variables, candidate)
+		}
+	}
+
+	return variables, nil
+}
+
+// parseSnapshotVariables parses `Input=literal` assignments.
+func parseSnapshotVariables(assignments []string) ([]cradleTemplate.SnapshotVariable, error) {
+	var variables []cradleTemplate.SnapshotVariable
+
+	for _, assignment := range assignments {
+		input, literal, ok := strings.Cut(assignment, "=")
+		input = strings.TrimSpace(input)
+		if !ok || input == "" || literal == "" {
+			return nil, fmt.Errorf("invalid --var %q, expected Input=literal", assignment)
+		}
+
+		variables = append(variables, cradleTemplate.SnapshotVariable{Input: input, Literal: literal})
+	}
+
+	return variables, nil
+}
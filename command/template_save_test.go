@@ -0,0 +1,75 @@
+package command
+
+import (
+	"embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+func TestSaveTemplateOverDirectoryTemplate(t *testing.T) {
+	t.Setenv(config.EnvCradleHome, t.TempDir())
+
+	err := config.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cradleTemplate.SetTemplateFS(embed.FS{})
+
+	projectPath := filepath.Join(t.TempDir(), "demo")
+	err = os.MkdirAll(projectPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(projectPath, "README.md"), []byte("# notes\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.AddProject(types.CradleProject{Path: projectPath, CreatedAt: time.Now(), CreatedBy: "cradle"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateDirPath := filepath.Join(config.Get().ConfigDirPath, config.CradleTemplatesDir)
+	existingDirPath := filepath.Join(templateDirPath, "saved")
+	err = os.MkdirAll(existingDirPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(existingDirPath, "template.yaml"), []byte("version: v1\nname: saved\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := saveTemplateParams{Project: "demo", Name: "saved", NoInput: true}
+
+	err = saveTemplate(params)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("saveTemplate() error = %v, want the directory template to be kept", err)
+	}
+
+	params.Force = true
+	err = saveTemplate(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(existingDirPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("directory template was not replaced: %v", err)
+	}
+
+	td, err := cradleTemplate.GetTemplate("saved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := td.Files["README.md"].Content, "# notes\n"; got != want {
+		t.Errorf("README.md = %q, want %q", got, want)
+	}
+}
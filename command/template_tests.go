@@ -0,0 +1,329 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/gurleensethi/cradle/internal/types"
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Layout of the test cases of a template: tests/<case>/inputs.yaml holds the
+// input values, tests/<case>/generated.yaml optionally the values of now,
+// year, uuid and the git helpers, and tests/<case>/expected the files the
+// template must render.
+const (
+	templateTestsDirName  = "tests"
+	testInputsFileName    = "inputs.yaml"
+	testGeneratedFileName = "generated.yaml"
+	testExpectedDirName   = "expected"
+)
+
+// testGeneratedTime and testGitConfig are what now, year and the git helpers
+// return in test cases unless generated.yaml sets other values, so cases
+// render the same on every machine and in every year.
+var (
+	testGeneratedTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	testGitConfig     = map[string]string{
+		"user.name":   "Cradle Test",
+		"user.email":  "test@example.com",
+		"github.user": "cradle-test",
+	}
+)
+
+var (
+	testPassStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	testFailStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+)
+
+// templateTest returns the command rendering the test cases of a template and
+// comparing them with the expected files.
+func templateTest() *cli.Command {
+	return &cli.Command{
+		Name:  "test",
+		Usage: "Render the test cases of a template directory and compare them with the expected files",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "dir",
+				UsageText: "path to the template directory",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "update",
+				Usage: "replace the expected files of every case with the rendered files",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			dirPath := c.StringArg("dir")
+			if dirPath == "" {
+				return fmt.Errorf("provide a template directory")
+			}
+
+			return testTemplate(dirPath, c.Bool("update"))
+		},
+	}
+}
+
+// testTemplate renders every test case of the template in dirPath and
+// reports the cases whose files differ from the expected ones.
+func testTemplate(dirPath string, update bool) error {
+	templateData, err := cradleTemplate.LoadTemplateFile(dirPath)
+	if err != nil {
+		return err
+	}
+
+	testsDirPath := filepath.Join(dirPath, templateTestsDirName)
+
+	entries, err := os.ReadDir(testsDirPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var cases []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			cases = append(cases, entry.Name())
+		}
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("template %s has no test cases, add them as directories in %s", dirPath, testsDirPath)
+	}
+
+	failed := 0
+
+	for _, testCase := range cases {
+		casePath := filepath.Join(testsDirPath, testCase)
+
+		files, err := renderTestCase(templateData, casePath)
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s\n      %v\n", testFailStyle.Render("FAIL"), testCase, err)
+			continue
+		}
+
+		expectedPath := filepath.Join(casePath, testExpectedDirName)
+
+		if update {
+			err = writeExpectedFiles(expectedPath, files)
+			if err != nil {
+				return fmt.Errorf("update case %s: %w", testCase, err)
+			}
+			fmt.Printf("updated %s (%d files)\n", testCase, len(files))
+			continue
+		}
+
+		if _, err := os.Stat(expectedPath); errors.Is(err, os.ErrNotExist) {
+			failed++
+			fmt.Printf("%s %s\n      no expected files in %s, run with --update to create them\n", testFailStyle.Render("FAIL"), testCase, expectedPath)
+			continue
+		}
+
+		expected, err := readExpectedFiles(expectedPath)
+		if err != nil {
+			return fmt.Errorf("case %s: %w", testCase, err)
+		}
+
+		if compareTestCase(testCase, expected, files) {
+			fmt.Printf("%s   %s\n", testPassStyle.Render("ok"), testCase)
+		} else {
+			failed++
+		}
+	}
+
+	if update {
+		return nil
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cases failed, run with --update to accept the rendered files", failed, len(cases))
+	}
+
+	fmt.Printf("All %d cases passed\n", len(cases))
+
+	return nil
+}
+
+// renderTestCase renders the template with the inputs of a test case, never
+// prompting. The project is named after the case unless inputs.yaml sets
+// ProjectName. UUIDs not set in generated.yaml are numbered instead of random.
+func renderTestCase(templateData *cradleTemplate.TemplateData, casePath string) ([]cradleTemplate.RenderedFile, error) {
+	values := make(map[string]string)
+
+	inputsPath := filepath.Join(casePath, testInputsFileName)
+	if _, err := os.Stat(inputsPath); err == nil {
+		values, err = cradleTemplate.ReadValuesFile(inputsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	projectName := filepath.Base(casePath)
+	if name, exists := values["ProjectName"]; exists {
+		projectName = name
+		delete(values, "ProjectName")
+	}
+
+	generated, err := readTestGenerated(casePath)
+	if err != nil {
+		return nil, err
+	}
+
+	files, _, err := renderProjectFiles(templateData, projectName, cradleTemplate.InputOptions{
+		Values:    values,
+		NoInput:   true,
+		Generated: &generated,
+	})
+	return files, err
+}
+
+// readTestGenerated reads the generated values of a test case, the test
+// defaults for the values generated.yaml does not set.
+func readTestGenerated(casePath string) (types.Generated, error) {
+	var generated types.Generated
+
+	generatedPath := filepath.Join(casePath, testGeneratedFileName)
+
+	data, err := os.ReadFile(generatedPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return generated, err
+	}
+
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	yamlDecoder.KnownFields(true)
+
+	err = yamlDecoder.Decode(&generated)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return generated, fmt.Errorf("parse %s: %w", generatedPath, err)
+	}
+
+	if generated.Time.IsZero() {
+		generated.Time = testGeneratedTime
+	}
+
+	git := maps.Clone(testGitConfig)
+	maps.Copy(git, generated.Git)
+	generated.Git = git
+
+	generated.Sequential = true
+
+	return generated, nil
+}
+
+// readExpectedFiles reads the expected files of a test case by slash separated path.
+func readExpectedFiles(expectedPath string) (map[string]cradleTemplate.RenderedFile, error) {
+	expected := make(map[string]cradleTemplate.RenderedFile)
+
+	err := filepath.WalkDir(expectedPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(expectedPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		expected[filepath.ToSlash(relativePath)] = cradleTemplate.RenderedFile{
+			Path:    filepath.ToSlash(relativePath),
+			Content: content,
+			Mode:    info.Mode().Perm(),
+		}
+
+		return nil
+	})
+
+	return expected, err
+}
+
+// compareTestCase prints the differences between the expected and the
+// rendered files of a test case and reports whether there were none.
+func compareTestCase(testCase string, expected map[string]cradleTemplate.RenderedFile, files []cradleTemplate.RenderedFile) bool {
+	rendered := make(map[string]cradleTemplate.RenderedFile, len(files))
+	for _, file := range files {
+		rendered[file.Path] = file
+	}
+
+	filePaths := slices.Sorted(maps.Keys(expected))
+	for filePath := range rendered {
+		if _, exists := expected[filePath]; !exists {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	slices.Sort(filePaths)
+
+	passed := true
+	fail := func(format string, args ...any) {
+		if passed {
+			fmt.Printf("%s %s\n", testFailStyle.Render("FAIL"), testCase)
+			passed = false
+		}
+		fmt.Printf("      "+format+"\n", args...)
+	}
+
+	for _, filePath := range filePaths {
+		expectedFile, isExpected := expected[filePath]
+		renderedFile, isRendered := rendered[filePath]
+
+		switch {
+		case !isRendered:
+			fail("%s is expected but not rendered", filePath)
+		case !isExpected:
+			fail("%s is rendered but not expected", filePath)
+			printFileDiff(filePath, nil, renderedFile.Content)
+		case !bytes.Equal(expectedFile.Content, renderedFile.Content):
+			fail("%s differs from the expected file", filePath)
+			printFileDiff(filePath, expectedFile.Content, renderedFile.Content)
+		case expectedFile.Mode&0o111 != renderedFile.Mode&0o111:
+			fail("%s is rendered with mode %s, expected %s", filePath, renderedFile.Mode, expectedFile.Mode)
+		}
+	}
+
+	return passed
+}
+
+// writeExpectedFiles replaces the expected files of a test case with the rendered files.
+func writeExpectedFiles(expectedPath string, files []cradleTemplate.RenderedFile) error {
+	err := os.RemoveAll(expectedPath)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		err = writeProjectFile(filepath.Join(expectedPath, filepath.FromSlash(file.Path)), file.Content, file.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+)
+
+func TestTemplateTestIsReproducible(t *testing.T) {
+	t.Setenv(config.EnvCradleHome, t.TempDir())
+
+	err := config.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cradleTemplate.SetTemplateFS(embed.FS{})
+
+	templatePath := t.TempDir()
+	files := map[string]string{
+		"template.yaml": `version: v1
+name: reproducible
+inputs:
+  - name: ModuleName
+    default: "github.com/{{githubUser}}/{{.ProjectName}}"
+  - name: ID
+    computed: "{{uuid}}"
+`,
+		"files/LICENSE.tmpl":  "Copyright (c) {{year}} {{gitUser}} <{{gitEmail}}>\n",
+		"files/ids.txt.tmpl":  "{{.ModuleName}} {{.ID}} {{uuid}} {{uuid}} {{now.Format \"2006-01-02\"}}\n",
+		"tests/default/.keep": "",
+		"tests/pinned/generated.yaml": `time: 2024-06-01T12:00:00Z
+uuids:
+  ids.txt: [0b0e7a8c-53d4-4d8e-9d0c-2f1f5c1e8a11]
+git:
+  user.name: Jane Doe
+  github.user: jdoe
+`,
+	}
+	for name, content := range files {
+		filePath := filepath.Join(templatePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = testTemplate(templatePath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rendering again must reproduce the expected files.
+	err = testTemplate(templatePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "tests/default/expected/LICENSE",
+			want: "Copyright (c) 2000 Cradle Test <test@example.com>\n",
+		},
+		{
+			path: "tests/default/expected/ids.txt",
+			want: "github.com/cradle-test/default 800b5e9e-0000-4000-8000-000000000001 " +
+				"221144ef-0000-4000-8000-000000000001 221144ef-0000-4000-8000-000000000002 2000-01-01\n",
+		},
+		{
+			path: "tests/pinned/expected/LICENSE",
+			want: "Copyright (c) 2024 Jane Doe <test@example.com>\n",
+		},
+		{
+			path: "tests/pinned/expected/ids.txt",
+			want: "github.com/jdoe/pinned 800b5e9e-0000-4000-8000-000000000001 " +
+				"0b0e7a8c-53d4-4d8e-9d0c-2f1f5c1e8a11 221144ef-0000-4000-8000-000000000002 2024-06-01\n",
+		},
+	}
+
+	for _, test := range tests {
+		content, err := os.ReadFile(filepath.Join(templatePath, filepath.FromSlash(test.path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.want {
+			t.Errorf("%s = %q, want %q", test.path, content, test.want)
+		}
+	}
+}
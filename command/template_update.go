@@ -0,0 +1,57 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/urfave/cli/v3"
+)
+
+// templateUpdate returns the command refreshing the cached templates of registries.
+func templateUpdate() *cli.Command {
+	return &cli.Command{
+		Name:  "update",
+		Usage: "Refresh the cached templates of a registry, or of every registry",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "source",
+				UsageText: "path to the registry folder, defaults to every added registry",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			sources := []string{c.StringArg("source")}
+
+			if sources[0] == "" {
+				registries, err := cradleTemplate.Registries()
+				if err != nil {
+					return err
+				}
+				if len(registries) == 0 {
+					fmt.Println("No registries added")
+					return nil
+				}
+
+				sources = nil
+				for _, registry := range registries {
+					sources = append(sources, registry.Path)
+				}
+			}
+
+			for _, source := range sources {
+				registry, err := cradleTemplate.UpdateRegistry(source)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("Updated registry %s\n", registry.Path)
+				printRegistryTemplates(registry)
+			}
+
+			return nil
+		},
+	}
+}
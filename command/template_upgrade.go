@@ -0,0 +1,394 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/gurleensethi/cradle/internal/diff"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+	"github.com/gurleensethi/cradle/internal/types"
+	"github.com/mattn/go-isatty"
+	"github.com/urfave/cli/v3"
+)
+
+// templateUpgrade returns the command bringing a project up to date with its template.
+func templateUpgrade() *cli.Command {
+	return &cli.Command{
+		Name:  "upgrade",
+		Usage: "Merge changes of a template into a project created from it",
+		Arguments: []cli.Argument{
+			&cli.StringArg{
+				Name:      "project",
+				UsageText: "name or path of the project to upgrade",
+				Config: cli.StringConfig{
+					TrimSpace: true,
+				},
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "`name` of an applied template to upgrade, defaults to the template the project was created from",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "move to another `version` of a registry template, \"latest\" follows its latest version",
+			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "set a template input as `Key=Value`, can be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "values",
+				Usage: "read template inputs from a YAML or JSON `file`",
+			},
+			&cli.BoolFlag{
+				Name:  "no-input",
+				Usage: "never prompt, use recorded answers and defaults for new inputs",
+			},
+		},
+		// Values passed with --set may contain commas.
+		DisableSliceFlagSeparator: true,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			projectQuery := c.StringArg("project")
+			if projectQuery == "" {
+				return fmt.Errorf("provide a project name")
+			}
+
+			inputValues, err := readInputValues(c.String("values"), c.StringSlice("set"))
+			if err != nil {
+				return err
+			}
+
+			return upgradeTemplate(upgradeTemplateParams{
+				Project:     projectQuery,
+				Template:    c.String("template"),
+				To:          c.String("to"),
+				InputValues: inputValues,
+				NoInput:     c.Bool("no-input") || !isatty.IsTerminal(os.Stdin.Fd()),
+			})
+		},
+	}
+}
+
+type upgradeTemplateParams struct {
+	Project  string
+	Template string
+	// To is the registry version to move to, "latest" to drop the pinned version.
+	To string
+	// InputValues are template inputs provided upfront, they take precedence over recorded answers.
+	InputValues map[string]string
+	NoInput     bool
+}
+
+// Outcomes of upgrading a single project file.
+const (
+	upgradeUpdated  = "updated"
+	upgradeMerged   = "merged"
+	upgradeConflict = "conflict"
+	upgradeCreated  = "created"
+	upgradeDeleted  = "deleted"
+	upgradeKept     = "kept"
+	upgradeSkipped  = "skipped"
+)
+
+// upgradeTemplate renders the template version recorded for the project and
+// the current version with the recorded inputs, and merges the difference
+// into the project files. Lines changed both in the project and in the
+// template are left with conflict markers.
+func upgradeTemplate(params upgradeTemplateParams) error {
+	project, err := openProject(params.Project)
+	if err != nil {
+		return err
+	}
+
+	templateName := params.Template
+	if templateName == "" {
+		templateName = project.Template
+	}
+	if templateName == "" {
+		return fmt.Errorf("project %s was not created from a template, pass --template to upgrade an applied one", project.GetPathWithTruncatedHome())
+	}
+
+	applied, found := project.AppliedTemplate(templateName)
+	if !found {
+		// The template may be recorded pinned to a version, e.g. svc@1.0.0 for svc.
+		i := slices.IndexFunc(project.Templates, func(a types.AppliedTemplate) bool {
+			name, _ := cradleTemplate.SplitVersion(a.Name)
+			return name == templateName
+		})
+		if i < 0 {
+			return fmt.Errorf("template %s was never applied to project %s", templateName, project.GetPathWithTruncatedHome())
+		}
+		applied = project.Templates[i]
+		templateName = applied.Name
+	}
+	if applied.Version == "" {
+		return fmt.Errorf("project %s has no recorded version of template %s to upgrade from", project.GetPathWithTruncatedHome(), templateName)
+	}
+
+	oldTemplate, err := cradleTemplate.LoadVersion(templateName, applied.Version)
+	if err != nil {
+		return err
+	}
+
+	// A pinned version stays pinned unless --to moves it.
+	newTemplateName := templateName
+	switch name, _ := cradleTemplate.SplitVersion(templateName); params.To {
+	case "":
+	case "latest":
+		newTemplateName = name
+	default:
+		newTemplateName = cradleTemplate.JoinVersion(name, params.To)
+	}
+
+	newTemplate, err := cradleTemplate.GetTemplate(newTemplateName)
+	if err != nil {
+		if errors.Is(err, cradleTemplate.ErrNotExists) {
+			return fmt.Errorf("template %s does not exist", newTemplateName)
+		}
+		return err
+	}
+
+	for _, warning := range newTemplate.Warnings {
+		fmt.Println("warning:", warning)
+	}
+
+	newVersion, err := cradleTemplate.Version(newTemplate)
+	if err != nil {
+		return err
+	}
+
+	if newVersion == applied.Version && newTemplateName == templateName && len(params.InputValues) == 0 {
+		fmt.Printf("Project %s is up to date with template %s\n", project.GetPathWithTruncatedHome(), templateName)
+		if _, version := cradleTemplate.SplitVersion(templateName); version != "" {
+			fmt.Println("The project is pinned to this version, pass --to <version> or --to latest to move to another one.")
+		}
+		return nil
+	}
+
+	if newTemplateName != templateName {
+		// The record of the old version is replaced by the one of the new version.
+		for i := range project.Templates {
+			if project.Templates[i].Name == templateName {
+				project.Templates[i].Name = newTemplateName
+			}
+		}
+		if project.Template == templateName {
+			project.Template = newTemplateName
+		}
+	}
+
+	// Both versions are rendered with the times and UUIDs of the first
+	// render, so only changes of the template show up. Projects recorded
+	// before they were kept fall back to the time the template was applied.
+	generated := applied.Generated
+	if generated.Time.IsZero() {
+		generated.Time = applied.AppliedAt
+	}
+
+	newValues := rememberedInputs(project, newTemplate)
+	maps.Copy(newValues, params.InputValues)
+
+	builtins := map[string]any{
+		"ProjectName": filepath.Base(project.Path),
+	}
+
+	newInputs, err := cradleTemplate.ReadInputs(newTemplate, cradleTemplate.InputOptions{
+		Values:    newValues,
+		NoInput:   params.NoInput,
+		Builtins:  builtins,
+		Generated: &generated,
+	})
+	if err != nil {
+		return err
+	}
+
+	oldInputs, err := cradleTemplate.ReadInputs(oldTemplate, cradleTemplate.InputOptions{
+		Values:    oldInputValues(oldTemplate, applied, newInputs),
+		NoInput:   true,
+		Builtins:  builtins,
+		Generated: &generated,
+	})
+	if err != nil {
+		return fmt.Errorf("inputs of the recorded version of template %s: %w", templateName, err)
+	}
+
+	maps.Copy(oldInputs, builtins)
+	maps.Copy(newInputs, builtins)
+
+	oldFiles, err := renderFilesByPath(oldTemplate, oldInputs, &generated)
+	if err != nil {
+		return fmt.Errorf("render recorded version of template %s: %w", templateName, err)
+	}
+
+	newFiles, err := renderFilesByPath(newTemplate, newInputs, &generated)
+	if err != nil {
+		return err
+	}
+
+	filePaths := slices.Sorted(maps.Keys(oldFiles))
+	for filePath := range newFiles {
+		if _, exists := oldFiles[filePath]; !exists {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	slices.Sort(filePaths)
+
+	counts := make(map[string]int)
+
+	for _, filePath := range filePaths {
+		outcome, err := upgradeFile(project.Path, filePath, oldFiles[filePath], newFiles[filePath])
+		if err != nil {
+			return err
+		}
+		if outcome == "" {
+			continue
+		}
+
+		counts[outcome]++
+
+		switch outcome {
+		case upgradeConflict:
+			fmt.Printf("  %-9s %s\n", outcome, filePath)
+		case upgradeKept:
+			fmt.Printf("  %-9s %s (removed from the template, changed in the project)\n", outcome, filePath)
+		case upgradeSkipped:
+			fmt.Printf("  %-9s %s (deleted in the project)\n", outcome, filePath)
+		default:
+			fmt.Printf("  %-9s %s\n", outcome, filePath)
+		}
+	}
+
+	version, err := cradleTemplate.SaveVersion(newTemplate)
+	if err != nil {
+		return err
+	}
+
+	project.SetAppliedTemplate(types.AppliedTemplate{
+		Name:      newTemplateName,
+		Version:   version,
+		Inputs:    cradleTemplate.FormatInputs(newTemplate, newInputs),
+		AppliedAt: time.Now(),
+		Generated: generated,
+	})
+
+	err = config.UpdateProject(project)
+	if err != nil {
+		return fmt.Errorf("record template version: %w", err)
+	}
+
+	fmt.Printf("Upgraded %s to template %s %s: %d updated, %d merged, %d created, %d deleted, %d with conflicts\n",
+		project.GetPathWithTruncatedHome(), newTemplateName, version,
+		counts[upgradeUpdated], counts[upgradeMerged], counts[upgradeCreated], counts[upgradeDeleted], counts[upgradeConflict])
+
+	if counts[upgradeConflict] > 0 {
+		return fmt.Errorf("%d files have conflicts, resolve the conflict markers or the .new files", counts[upgradeConflict])
+	}
+
+	return nil
+}
+
+// oldInputValues returns the values to render the recorded template version
+// with: the recorded answers, and the secrets just entered since they are
+// never recorded.
+func oldInputValues(oldTemplate *cradleTemplate.TemplateData, applied types.AppliedTemplate, newInputs map[string]any) map[string]string {
+	values := make(map[string]string)
+
+	for _, input := range oldTemplate.Inputs {
+		if value, exists := applied.Inputs[input.Name]; exists {
+			values[input.Name] = value
+		} else if secret, ok := newInputs[input.Name].(string); ok && input.IsSecret() {
+			values[input.Name] = secret
+		}
+	}
+
+	return values
+}
+
+// renderFilesByPath renders the template and indexes the files by path.
+func renderFilesByPath(templateData *cradleTemplate.TemplateData, inputs map[string]any, generated *types.Generated) (map[string]*cradleTemplate.RenderedFile, error) {
+	files, err := cradleTemplate.Render(templateData, inputs, generated)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByPath := make(map[string]*cradleTemplate.RenderedFile, len(files))
+	for _, file := range files {
+		filesByPath[file.Path] = &file
+	}
+
+	return filesByPath, nil
+}
+
+// upgradeFile brings one project file from the old to the new rendering of
+// the template and returns what happened to it, empty when nothing changed.
+// oldFile or newFile is nil when the file is not part of that version.
+func upgradeFile(projectPath, filePath string, oldFile, newFile *cradleTemplate.RenderedFile) (string, error) {
+	fullPath := filepath.Join(projectPath, filepath.FromSlash(filePath))
+
+	current, err := os.ReadFile(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		current = nil
+	} else if err != nil {
+		return "", err
+	}
+
+	switch {
+	case newFile == nil:
+		// The template no longer creates the file, remove it unless it was changed.
+		if current == nil {
+			return "", nil
+		}
+		if !bytes.Equal(current, oldFile.Content) {
+			return upgradeKept, nil
+		}
+		return upgradeDeleted, os.Remove(fullPath)
+
+	case oldFile != nil && bytes.Equal(oldFile.Content, newFile.Content) && oldFile.Mode == newFile.Mode:
+		// The template did not change the file.
+		return "", nil
+
+	case current == nil:
+		if oldFile != nil {
+			return upgradeSkipped, nil
+		}
+		return upgradeCreated, writeProjectFile(fullPath, newFile.Content, newFile.Mode)
+
+	case bytes.Equal(current, newFile.Content):
+		return "", os.Chmod(fullPath, newFile.Mode)
+
+	case oldFile != nil && bytes.Equal(current, oldFile.Content):
+		return upgradeUpdated, writeProjectFile(fullPath, newFile.Content, newFile.Mode)
+	}
+
+	var base []byte
+	if oldFile != nil {
+		base = oldFile.Content
+	}
+
+	// Binary files cannot hold conflict markers, the new version is written next to them.
+	if bytes.IndexByte(base, 0) >= 0 || bytes.IndexByte(current, 0) >= 0 || bytes.IndexByte(newFile.Content, 0) >= 0 {
+		return upgradeConflict, writeProjectFile(fullPath+".new", newFile.Content, newFile.Mode)
+	}
+
+	merged := diff.Merge(string(base), string(current), string(newFile.Content), "project", "template")
+
+	err = writeProjectFile(fullPath, []byte(merged.Text), newFile.Mode)
+	if err != nil {
+		return "", err
+	}
+
+	if merged.Conflicts > 0 {
+		return upgradeConflict, nil
+	}
+	return upgradeMerged, nil
+}
@@ -0,0 +1,254 @@
+package command
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"github.com/gurleensethi/cradle/internal/diff"
+	cradleTemplate "github.com/gurleensethi/cradle/internal/template"
+)
+
+// upgradeTestTemplate renders values that change on every call, next to a
+// README whose text can be changed between the versions of the template.
+const upgradeTestTemplate = `version: v1
+name: upgrade-test
+description: Template for upgrade tests
+inputs:
+  - name: Title
+    default: demo
+  - name: ID
+    computed: "{{uuid}}"
+files:
+  generated.txt: |
+    created {{now.Format "2006-01-02T15:04:05.999999999Z07:00"}} in {{year}}
+    file {{uuid}} {{uuid}}
+    input {{.ID}}
+  README.md: |
+    # {{.Title}}
+    %s
+`
+
+// writeUpgradeTestTemplate writes the test template with the README text.
+func writeUpgradeTestTemplate(t *testing.T, readme string) {
+	t.Helper()
+
+	templateDirPath := filepath.Join(config.Get().ConfigDirPath, config.CradleTemplatesDir)
+	err := os.MkdirAll(templateDirPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte(fmt.Sprintf(upgradeTestTemplate, readme))
+	err = os.WriteFile(filepath.Join(templateDirPath, "upgrade-test.yaml"), content, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readProjectFiles returns the content and modification time of the project files by name.
+func readProjectFiles(t *testing.T, projectPath string) (map[string]string, map[string]time.Time) {
+	t.Helper()
+
+	entries, err := os.ReadDir(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make(map[string]string)
+	modTimes := make(map[string]time.Time)
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(projectPath, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		contents[entry.Name()] = string(content)
+		modTimes[entry.Name()] = info.ModTime()
+	}
+
+	return contents, modTimes
+}
+
+func TestUpgradeTemplateKeepsGeneratedValues(t *testing.T) {
+	tests := []struct {
+		name string
+		// readme is the README text of the upgraded template.
+		readme string
+		// inputValues are passed to the upgrade.
+		inputValues map[string]string
+		// wantChanged are the files the upgrade must change.
+		wantChanged []string
+	}{
+		{
+			name:        "no-op upgrade",
+			readme:      "first",
+			inputValues: map[string]string{"Title": "demo"},
+		},
+		{
+			name:        "unrelated change",
+			readme:      "second",
+			wantChanged: []string{"README.md"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(config.EnvCradleHome, t.TempDir())
+
+			err := config.Init()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cradleTemplate.SetTemplateFS(embed.FS{})
+
+			writeUpgradeTestTemplate(t, "first")
+
+			projectPath, err := createProject(createProjectParams{
+				Name:     "demo",
+				Template: "upgrade-test",
+				NoInput:  true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before, modTimesBefore := readProjectFiles(t, projectPath)
+
+			// Let a rewritten file get a different modification time.
+			time.Sleep(20 * time.Millisecond)
+
+			writeUpgradeTestTemplate(t, test.readme)
+
+			err = upgradeTemplate(upgradeTemplateParams{
+				Project:     projectPath,
+				InputValues: test.inputValues,
+				NoInput:     true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			after, modTimesAfter := readProjectFiles(t, projectPath)
+
+			for name, content := range before {
+				changed := content != after[name] || !modTimesBefore[name].Equal(modTimesAfter[name])
+				wantChanged := slices.Contains(test.wantChanged, name)
+
+				if changed != wantChanged {
+					t.Errorf("%s changed = %v, want %v\nbefore:\n%s\nafter:\n%s", name, changed, wantChanged, content, after[name])
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeTemplateMergesProjectChanges(t *testing.T) {
+	const (
+		oldTemplate = `version: v1
+name: service
+files:
+  README.md: "# {{.ProjectName}}\n\nintro\n\nfirst\n"
+  old.txt: "old\n"
+`
+		newTemplate = `version: v1
+name: service
+files:
+  README.md: "# {{.ProjectName}}\n\nintro\n\nsecond\n"
+  new.txt: "new\n"
+`
+	)
+
+	tests := []struct {
+		name string
+		// readme is the README of the project before the upgrade.
+		readme string
+		// wantReadme are parts of the README after the upgrade.
+		wantReadme   []string
+		wantConflict bool
+	}{
+		{
+			name:       "merged",
+			readme:     "# demo\n\nlocal intro\n\nfirst\n",
+			wantReadme: []string{"# demo\n\nlocal intro\n\nsecond\n"},
+		},
+		{
+			name:         "conflict",
+			readme:       "# demo\n\nintro\n\nmine\n",
+			wantReadme:   []string{diff.MarkerOurs + " project\nmine\n", "second\n" + diff.MarkerTheirs + " template\n"},
+			wantConflict: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			homePath := t.TempDir()
+			t.Setenv(config.EnvCradleHome, homePath)
+
+			err := config.Init()
+			if err != nil {
+				t.Fatal(err)
+			}
+			cradleTemplate.SetTemplateFS(embed.FS{})
+
+			templateDirPath := filepath.Join(homePath, config.CradleTemplatesDir)
+			templateFilePath := filepath.Join(templateDirPath, "service.yaml")
+			err = os.MkdirAll(templateDirPath, 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(templateFilePath, []byte(oldTemplate), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectPath, err := createProject(createProjectParams{Name: "demo", Template: "service", NoInput: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = os.WriteFile(filepath.Join(projectPath, "README.md"), []byte(test.readme), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(templateFilePath, []byte(newTemplate), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = upgradeTemplate(upgradeTemplateParams{Project: projectPath, NoInput: true})
+			if test.wantConflict != (err != nil) {
+				t.Fatalf("upgradeTemplate() error = %v, want a conflict %v", err, test.wantConflict)
+			}
+
+			readme, err := os.ReadFile(filepath.Join(projectPath, "README.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.wantReadme {
+				if !strings.Contains(string(readme), want) {
+					t.Errorf("README.md =\n%s\nwant it to contain\n%s", readme, want)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(projectPath, "old.txt")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("old.txt was not deleted: %v", err)
+			}
+			content, err := os.ReadFile(filepath.Join(projectPath, "new.txt"))
+			if err != nil || string(content) != "new\n" {
+				t.Errorf("new.txt = %q, %v, want it to be created", content, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gurleensethi/cradle/internal/types"
+	"github.com/mattn/go-isatty"
+)
+
+// isTerminal reports whether stdin is a terminal to ask the user on.
+var isTerminal = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// backupGenerations is the number of previous versions of the config file
+// kept as cradle.yaml.bak.1 (the newest) to cradle.yaml.bak.N.
+const backupGenerations = 3
+
+// WriteFileAtomic replaces the file with data so that readers, and the file
+// after a crash, see either the old or the new content but never a mix: data
+// is written to a temporary file in the same directory, synced and renamed
+// over the file.
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) (err error) {
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	_, err = tempFile.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = tempFile.Chmod(perm)
+	if err != nil {
+		return err
+	}
+
+	err = tempFile.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = tempFile.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tempFile.Name(), filePath)
+	if err != nil {
+		return err
+	}
+
+	syncDir(filepath.Dir(filePath))
+
+	return nil
+}
+
+// syncDir makes a rename in the directory durable. Not every platform can
+// sync directories, failures are ignored.
+func syncDir(dirPath string) {
+	dir, err := os.Open(dirPath)
+	if err != nil {
+		return
+	}
+	defer dir.Close()
+
+	_ = dir.Sync()
+}
+
+func backupFilePath(configFilePath string, generation int) string {
+	return fmt.Sprintf("%s.bak.%d", configFilePath, generation)
+}
+
+// rotateBackups shifts the backups of the config file by one generation,
+// dropping the oldest, and saves the current config file as the newest.
+func rotateBackups(configFilePath string) error {
+	current, err := os.ReadFile(configFilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// There is nothing worth keeping in an empty or corrupt file.
+	if _, err := parseConfig(current); err != nil || isBlank(current) {
+		return nil
+	}
+
+	for generation := backupGenerations - 1; generation >= 1; generation-- {
+		err = os.Rename(backupFilePath(configFilePath, generation), backupFilePath(configFilePath, generation+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return WriteFileAtomic(backupFilePath(configFilePath, 1), current, 0o644)
+}
+
+// backup is a readable backup of the config file.
+type backup struct {
+	path     string
+	projects []types.CradleProject
+}
+
+// newestGoodBackup returns the newest backup of the config file that can be parsed.
+func newestGoodBackup(configFilePath string) (backup, bool) {
+	for generation := 1; generation <= backupGenerations; generation++ {
+		backupPath := backupFilePath(configFilePath, generation)
+
+		data, err := os.ReadFile(backupPath)
+		if err != nil {
+			continue
+		}
+
+		projects, err := parseConfig(data)
+		if err != nil {
+			continue
+		}
+
+		return backup{path: backupPath, projects: projects}, true
+	}
+
+	return backup{}, false
+}
+
+// restoreBackup offers to replace a config file that cannot be read with its
+// newest good backup, keeping the broken file as cradle.yaml.corrupt. Without
+// a terminal to ask, the problem is reported instead.
+func restoreBackup(configFilePath string, readErr error) ([]types.CradleProject, error) {
+	good, found := newestGoodBackup(configFilePath)
+	if !found {
+		return nil, fmt.Errorf("read %s: %w", configFilePath, readErr)
+	}
+
+	if !isTerminal() {
+		return nil, fmt.Errorf("read %s: %w\nrestore the backup with: cp %s %s", configFilePath, readErr, good.path, configFilePath)
+	}
+
+	fmt.Printf("%s cannot be read: %v\n", configFilePath, readErr)
+	fmt.Printf("Restore it from %s with %d projects? (Y/N):", good.path, len(good.projects))
+
+	var confirmation string
+	fmt.Scanln(&confirmation)
+
+	if confirmation != "Y" && confirmation != "y" {
+		return nil, fmt.Errorf("read %s: %w", configFilePath, readErr)
+	}
+
+	unlock, err := lockConfig(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	corrupt, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	corruptPath := configFilePath + ".corrupt"
+
+	err = os.WriteFile(corruptPath, corrupt, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(good.path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = WriteFileAtomic(configFilePath, data, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Restored %s, the unreadable file was kept as %s\n", configFilePath, corruptPath)
+
+	return good.projects, nil
+}
+
+// isBlank reports whether the config file holds nothing but white space.
+func isBlank(data []byte) bool {
+	return strings.TrimSpace(string(data)) == ""
+}
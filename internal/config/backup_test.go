@@ -0,0 +1,289 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+func TestWriteFileAtomicReplacesFile(t *testing.T) {
+	dirPath := t.TempDir()
+	filePath := filepath.Join(dirPath, "cradle.yaml")
+
+	err := os.WriteFile(filePath, []byte("old content\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reader that opened the file before keeps reading the old content in
+	// full, the file is replaced rather than rewritten in place.
+	reader, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	err = WriteFileAtomic(filePath, []byte("new\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(old) != "old content\n" {
+		t.Errorf("open file = %q, want the old content", old)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("file = %q, want the new content", data)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %s, want %s", info.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	assertNoTempFiles(t, dirPath)
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dirPath := t.TempDir()
+
+	// A directory that is not empty cannot be replaced by the file.
+	filePath := filepath.Join(dirPath, "cradle.yaml")
+	err := os.MkdirAll(filepath.Join(filePath, "keep"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = WriteFileAtomic(filePath, []byte("new\n"), 0o644)
+	if err == nil {
+		t.Fatal("WriteFileAtomic() succeeded, want an error")
+	}
+
+	if _, err := os.Stat(filepath.Join(filePath, "keep")); err != nil {
+		t.Errorf("original was not kept: %v", err)
+	}
+
+	assertNoTempFiles(t, dirPath)
+}
+
+// TestFailedSaveKeepsConfig fails a save while backing up the config file,
+// which must leave the config file as it was.
+func TestFailedSaveKeepsConfig(t *testing.T) {
+	initTestConfig(t, "/a")
+
+	before, err := os.ReadFile(instance.CradleConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest backup cannot be replaced, so rotating the backups fails.
+	err = os.MkdirAll(filepath.Join(backupFilePath(instance.CradleConfigFilePath, backupGenerations), "keep"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(backupFilePath(instance.CradleConfigFilePath, backupGenerations-1), before, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = AddProject(types.CradleProject{Path: "/b"})
+	if err == nil {
+		t.Fatal("AddProject() succeeded, want an error")
+	}
+
+	after, err := os.ReadFile(instance.CradleConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("config file after a failed save =\n%s\nwant\n%s", after, before)
+	}
+}
+
+func TestBackupRotation(t *testing.T) {
+	initTestConfig(t)
+
+	paths := []string{"/a", "/b", "/c", "/d", "/e"}
+	for _, path := range paths {
+		err := AddProject(types.CradleProject{Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every save backs up the file it replaces: the newest backup lacks the
+	// last project, every older one one more.
+	for generation := 1; generation <= backupGenerations; generation++ {
+		data, err := os.ReadFile(backupFilePath(instance.CradleConfigFilePath, generation))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		projects, err := parseConfig(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, project := range projects {
+			got = append(got, project.Path)
+		}
+		if want := paths[:len(paths)-generation]; !slices.Equal(got, want) {
+			t.Errorf("backup %d projects = %v, want %v", generation, got, want)
+		}
+	}
+
+	if _, err := os.Stat(backupFilePath(instance.CradleConfigFilePath, backupGenerations+1)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("backup %d exists, want only %d backups: %v", backupGenerations+1, backupGenerations, err)
+	}
+}
+
+func TestInitRestoresNewestGoodBackup(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		answer   string
+		// wantPaths are the projects after Init, wantErr is part of its error otherwise.
+		wantPaths []string
+		wantErr   string
+	}{
+		{
+			name:      "confirmed",
+			terminal:  true,
+			answer:    "y\n",
+			wantPaths: []string{"/a"},
+		},
+		{
+			name:     "declined",
+			terminal: true,
+			answer:   "n\n",
+			wantErr:  "read ",
+		},
+		{
+			name:    "no terminal",
+			wantErr: "restore the backup with: cp ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initTestConfig(t, "/a", "/b", "/c")
+
+			configFilePath := instance.CradleConfigFilePath
+			corrupt := []byte("projects: [\n")
+
+			// Backup 1 holds /a and /b and backup 2 holds /a. Backup 1 is
+			// corrupt too, so backup 2 is restored.
+			err := os.WriteFile(configFilePath, corrupt, 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(backupFilePath(configFilePath, 1), []byte("version: [\n"), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			setTestTerminal(t, test.terminal, test.answer)
+
+			err = Init()
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Init() error = %v, want it to contain %q", err, test.wantErr)
+				}
+
+				data, err := os.ReadFile(configFilePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != string(corrupt) {
+					t.Errorf("config file = %q, want it to be left alone", data)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, project := range Projects() {
+				got = append(got, project.Path)
+			}
+			if !slices.Equal(got, test.wantPaths) {
+				t.Errorf("projects = %v, want %v", got, test.wantPaths)
+			}
+
+			if onDisk := projectsOnDisk(t); len(onDisk) != len(test.wantPaths) {
+				t.Errorf("projects on disk = %v, want %v", onDisk, test.wantPaths)
+			}
+
+			kept, err := os.ReadFile(configFilePath + ".corrupt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(kept) != string(corrupt) {
+				t.Errorf("corrupt file kept as %q, want %q", kept, corrupt)
+			}
+		})
+	}
+}
+
+// setTestTerminal makes stdin a terminal, or not, holding answer for the
+// duration of the test.
+func setTestTerminal(t *testing.T, terminal bool, answer string) {
+	t.Helper()
+
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stdinWriter.WriteString(answer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdinWriter.Close()
+
+	stdin := os.Stdin
+	os.Stdin = stdinReader
+	isTerminalBefore := isTerminal
+	isTerminal = func() bool { return terminal }
+
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		isTerminal = isTerminalBefore
+		stdinReader.Close()
+	})
+}
+
+// assertNoTempFiles fails the test when a temporary file of WriteFileAtomic was left in dirPath.
+func assertNoTempFiles(t *testing.T, dirPath string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temporary file %s was left behind", entry.Name())
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -13,22 +14,37 @@ import (
 )
 
 const (
-	EnvCradleHome        = "CRADLE_HOME"
-	CradleConfigFileName = "cradle.yaml"
+	EnvCradleHome         = "CRADLE_HOME"
+	EnvCradleTemplatePath = "CRADLE_TEMPLATE_PATH"
+	CradleConfigFileName  = "cradle.yaml"
+	CradleTemplatesDir    = "templates"
 
 	CradleConfigFileHeader = `# Code generated by cradle. DO NOT EDIT.`
 )
 
 // cradleConfig is used for YAML marshaling
 type cradleConfig struct {
+	// Version is the format version of the file, see configVersion.
+	Version  int                   `yaml:"version"`
 	Projects []types.CradleProject `yaml:"projects"`
 }
 
 type Config struct {
-	CradleHomeDirPath    string
+	// ConfigDirPath holds the project registry, the settings and user templates.
+	ConfigDirPath string
+	// CacheDirPath holds files that can be fetched again.
+	CacheDirPath string
+	// StateDirPath holds the state cradle keeps between runs, such as remembered answers.
+	StateDirPath         string
 	CradleConfigFilePath string
 	CradleCommandOut     bool
-	projects             []types.CradleProject
+	SettingsFilePath     string
+	Settings             Settings
+	// TemplateDirPaths lists the directories searched for user templates, highest precedence first.
+	TemplateDirPaths []string
+	projects         []types.CradleProject
+	// defaultProjectsDirPath is used when the projects_dir setting is not set.
+	defaultProjectsDirPath string
 }
 
 var instance Config
@@ -37,7 +53,7 @@ func Get() Config {
 	return instance
 }
 
-// Init initializes the config singleton from CRADLE_HOME env (default ~/cradle), ensures directories/files exist, parses projects from YAML.
+// Init initializes the config singleton from CRADLE_HOME env, or the XDG base directories when it is not set, ensures directories/files exist, parses projects from YAML and loads the settings.
 func Init() error {
 	instance = Config{}
 
@@ -46,30 +62,62 @@ func Init() error {
 		strings.TrimSpace(os.Getenv("CRADLE_CMDOUT")),
 	)
 
-	cradleHomePath, err := getCradleHomeDir()
+	dirs, err := resolveDirs()
 	if err != nil {
 		return err
 	}
 
-	err = ensureCradleHomeDir(cradleHomePath)
+	if strings.TrimSpace(os.Getenv(EnvCradleHome)) == "" {
+		dirs, err = migrateLegacyHome(dirs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dirPath := range []string{dirs.config, dirs.cache, dirs.state} {
+		err = ensureDir(dirPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	cradleConfigFilePath, err := ensureCradleConfigFile(dirs.config)
 	if err != nil {
 		return err
 	}
 
-	cradleConfigFilePath, err := ensureCradleConfigFile(cradleHomePath)
+	err = migrateConfigFile(cradleConfigFilePath)
 	if err != nil {
 		return err
 	}
 
 	projects, err := parseCradleConfigFile(cradleConfigFilePath)
+	if errors.Is(err, errNewerConfig) {
+		return fmt.Errorf("cannot read %s: %w", cradleConfigFilePath, err)
+	}
 	if err != nil {
-		return err
+		projects, err = restoreBackup(cradleConfigFilePath, err)
+		if err != nil {
+			return err
+		}
 	}
 
-	instance.CradleHomeDirPath = cradleHomePath
+	instance.ConfigDirPath = dirs.config
+	instance.CacheDirPath = dirs.cache
+	instance.StateDirPath = dirs.state
 	instance.CradleConfigFilePath = cradleConfigFilePath
+	instance.TemplateDirPaths = getTemplateDirPaths(dirs.config)
 	instance.projects = projects
 
+	instance.SettingsFilePath = path.Join(dirs.config, SettingsFileName)
+	instance.defaultProjectsDirPath = dirs.projects
+
+	var problems []error
+	instance.Settings, problems = loadSettings(instance.SettingsFilePath, dirs.projects)
+	for _, problem := range problems {
+		fmt.Println("warning:", problem)
+	}
+
 	return nil
 }
 
@@ -81,25 +129,46 @@ func Projects() []types.CradleProject {
 
 // AddProject appends the project to the list and persists config to disk.
 func AddProject(project types.CradleProject) error {
-	instance.projects = append(instance.projects, project)
-	return save()
+	return update(func(projects []types.CradleProject) ([]types.CradleProject, error) {
+		if slices.ContainsFunc(projects, func(p types.CradleProject) bool { return p.Path == project.Path }) {
+			return nil, fmt.Errorf("project already exists")
+		}
+		return append(projects, project), nil
+	})
 }
 
 // RemoveProjectByName removes a project matching the given name or path.
 func RemoveProjectByName(name string) error {
-	for i, project := range instance.projects {
-		if project.MatchPathOrName(name) {
-			instance.projects = append(instance.projects[:i], instance.projects[i+1:]...)
-			return save()
-		}
+	project, found := FindProject(name)
+	if !found {
+		return fmt.Errorf("project not found")
 	}
-	return fmt.Errorf("project not found")
+
+	return update(func(projects []types.CradleProject) ([]types.CradleProject, error) {
+		return slices.DeleteFunc(projects, func(p types.CradleProject) bool { return p.Path == project.Path }), nil
+	})
 }
 
-// UpdateProjects replaces the projects list and persists to disk.
-func UpdateProjects(projects []types.CradleProject) error {
-	instance.projects = projects
-	return save()
+// UpdateProject replaces the project with the same path and persists config to disk.
+func UpdateProject(project types.CradleProject) error {
+	return update(func(projects []types.CradleProject) ([]types.CradleProject, error) {
+		for i := range projects {
+			if projects[i].Path == project.Path {
+				projects[i] = project
+				return projects, nil
+			}
+		}
+		return nil, fmt.Errorf("project not found")
+	})
+}
+
+// RemoveProjects removes the projects with the given paths and persists
+// config to disk. Projects added or changed by other cradle processes since
+// Init are kept as they are on disk.
+func RemoveProjects(paths []string) error {
+	return update(func(projects []types.CradleProject) ([]types.CradleProject, error) {
+		return slices.DeleteFunc(projects, func(p types.CradleProject) bool { return slices.Contains(paths, p.Path) }), nil
+	})
 }
 
 // ForEachProject iterates projects calling fn on each; stops early if fn returns false.
@@ -141,52 +210,77 @@ func PermanentProjects() []types.CradleProject {
 	return permanent
 }
 
-// save writes the sorted configuration to the YAML config file.
-func save() error {
-	slices.SortFunc(instance.projects, func(a, b types.CradleProject) int {
-		return strings.Compare(a.Path, b.Path)
-	})
+// update applies fn to the projects on disk and saves the result while
+// holding the config lock, so that changes made by other cradle processes
+// since Init are merged rather than overwritten.
+func update(fn func([]types.CradleProject) ([]types.CradleProject, error)) error {
+	unlock, err := lockConfig(instance.CradleConfigFilePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	projects, err := parseCradleConfigFile(instance.CradleConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", instance.CradleConfigFilePath, err)
+	}
 
-	fileBytes, err := yaml.Marshal(cradleConfig{Projects: instance.projects})
+	projects, err = fn(projects)
 	if err != nil {
 		return err
 	}
 
-	fileBytes = append([]byte(CradleConfigFileHeader+"\n\n"), fileBytes...)
+	err = save(projects)
+	if err != nil {
+		return err
+	}
+
+	setUniqueNames(projects)
+	instance.projects = projects
 
-	return os.WriteFile(instance.CradleConfigFilePath, fileBytes, 0o666)
+	return nil
 }
 
-// getCradleHomeDir resolves the cradle home directory from the environment or returns the default.
-func getCradleHomeDir() (string, error) {
-	cradleHomePath := strings.TrimSpace(os.Getenv(EnvCradleHome))
-	if cradleHomePath == "" {
-		userHomeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", err
-		}
+// save writes the sorted projects to the YAML config file.
+func save(projects []types.CradleProject) error {
+	fileBytes, err := marshalConfig(projects)
+	if err != nil {
+		return err
+	}
 
-		cradleHomePath = path.Join(userHomeDir, "cradle")
+	err = rotateBackups(instance.CradleConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("back up %s: %w", instance.CradleConfigFilePath, err)
 	}
 
-	return cradleHomePath, nil
+	return WriteFileAtomic(instance.CradleConfigFilePath, fileBytes, 0o644)
 }
 
-// ensureCradleHomeDir creates the cradle home directory if it does not exist.
-func ensureCradleHomeDir(dirPath string) error {
-	dirStat, err := os.Stat(dirPath)
+// marshalConfig sorts the projects and encodes them as a config file of the current format version.
+func marshalConfig(projects []types.CradleProject) ([]byte, error) {
+	slices.SortFunc(projects, func(a, b types.CradleProject) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	fileBytes, err := yaml.Marshal(cradleConfig{Version: configVersion, Projects: projects})
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return os.MkdirAll(dirPath, os.ModePerm)
-		}
-		return err
+		return nil, err
 	}
 
-	if dirStat.IsDir() {
-		return nil
+	return append([]byte(CradleConfigFileHeader+"\n\n"), fileBytes...), nil
+}
+
+// getTemplateDirPaths returns the user template directories: entries of CRADLE_TEMPLATE_PATH in order, followed by the templates directory inside the config directory.
+func getTemplateDirPaths(configDirPath string) []string {
+	var dirPaths []string
+	for _, dirPath := range filepath.SplitList(os.Getenv(EnvCradleTemplatePath)) {
+		dirPath = strings.TrimSpace(dirPath)
+		if dirPath != "" {
+			dirPaths = append(dirPaths, dirPath)
+		}
 	}
 
-	return fmt.Errorf("%s is a file, either delete the file or change cradle home path by setting `CRADLE_HOME` env to something else", dirPath)
+	return append(dirPaths, path.Join(configDirPath, CradleTemplatesDir))
 }
 
 // ensureCradleConfigFile creates the config file if it does not exist and returns its path.
@@ -219,16 +313,26 @@ func ensureCradleConfigFile(dirPath string) (string, error) {
 	return configFilePath, nil
 }
 
-// parseCradleConfigFile reads and parses the config file, populating project unique names.
+// parseCradleConfigFile reads and parses the config file. A file emptied by
+// a crash is reported as an error when there is a backup to restore.
 func parseCradleConfigFile(configFilePath string) ([]types.CradleProject, error) {
-	var cradleConfig cradleConfig
-
 	configFile, err := os.ReadFile(configFilePath)
 	if err != nil {
 		return nil, err
 	}
 
-	err = yaml.Unmarshal(configFile, &cradleConfig)
+	if isBlank(configFile) {
+		if _, found := newestGoodBackup(configFilePath); found {
+			return nil, errors.New("the file is empty")
+		}
+	}
+
+	return parseConfig(configFile)
+}
+
+// parseConfig parses the content of a config file, populating project unique names.
+func parseConfig(data []byte) ([]types.CradleProject, error) {
+	cradleConfig, err := migrateConfig(data)
 	if err != nil {
 		return nil, err
 	}
@@ -237,8 +341,18 @@ func parseCradleConfigFile(configFilePath string) ([]types.CradleProject, error)
 		return strings.Compare(a.Path, b.Path)
 	})
 
+	setUniqueNames(cradleConfig.Projects)
+
+	return cradleConfig.Projects, nil
+}
+
+// setUniqueNames gives each project of the sorted list the shortest path
+// suffix not already taken by an earlier project.
+func setUniqueNames(projects []types.CradleProject) {
 	nameLookup := make(map[string]struct{})
-	for i, project := range cradleConfig.Projects {
+	for i, project := range projects {
+		projects[i].UniqueNameFromPath = ""
+
 		parts := strings.Split(project.Path, string(os.PathSeparator))
 		for j := len(parts) - 1; j >= 0; j-- {
 			candidateName := strings.Join(parts[j:], "/")
@@ -247,12 +361,10 @@ func parseCradleConfigFile(configFilePath string) ([]types.CradleProject, error)
 			}
 
 			if _, exists := nameLookup[candidateName]; !exists {
-				cradleConfig.Projects[i].UniqueNameFromPath = candidateName
+				projects[i].UniqueNameFromPath = candidateName
 				nameLookup[candidateName] = struct{}{}
 				break
 			}
 		}
 	}
-
-	return cradleConfig.Projects, nil
 }
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+// initTestConfig initializes the config in a new cradle home holding the projects.
+func initTestConfig(t *testing.T, paths ...string) {
+	t.Helper()
+
+	t.Setenv(EnvCradleHome, t.TempDir())
+
+	err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		err = AddProject(types.CradleProject{Path: path, CreatedAt: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// projectsOnDisk reads the projects from the config file.
+func projectsOnDisk(t *testing.T) map[string]types.CradleProject {
+	t.Helper()
+
+	projects, err := parseCradleConfigFile(instance.CradleConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byPath := make(map[string]types.CradleProject)
+	for _, project := range projects {
+		byPath[project.Path] = project
+	}
+	return byPath
+}
+
+// TestInterleavedUpdates runs a change of another cradle process between the
+// Init and the change of this one, which must not undo the other change.
+func TestInterleavedUpdates(t *testing.T) {
+	tests := []struct {
+		name string
+		// other is the change of the other process.
+		other func() error
+		// check verifies the projects on disk after both changes.
+		check func(t *testing.T, onDisk map[string]types.CradleProject)
+	}{
+		{
+			name: "update is kept",
+			other: func() error {
+				return UpdateProject(types.CradleProject{Path: "/b", Template: "go"})
+			},
+			check: func(t *testing.T, onDisk map[string]types.CradleProject) {
+				if onDisk["/b"].Template != "go" {
+					t.Errorf("update of /b was reverted: %+v", onDisk["/b"])
+				}
+			},
+		},
+		{
+			name: "removal is kept",
+			other: func() error {
+				return RemoveProjects([]string{"/c"})
+			},
+			check: func(t *testing.T, onDisk map[string]types.CradleProject) {
+				if _, exists := onDisk["/c"]; exists {
+					t.Error("removed project /c was brought back")
+				}
+			},
+		},
+		{
+			name: "addition is kept",
+			other: func() error {
+				return AddProject(types.CradleProject{Path: "/d"})
+			},
+			check: func(t *testing.T, onDisk map[string]types.CradleProject) {
+				if _, exists := onDisk["/d"]; !exists {
+					t.Error("added project /d was lost")
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initTestConfig(t, "/a", "/b", "/c")
+
+			// This process reads the config, then the other one changes it.
+			snapshot := instance
+			snapshot.projects = slices.Clone(instance.projects)
+
+			err := test.other()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			instance = snapshot
+
+			err = RemoveProjects([]string{"/a"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			onDisk := projectsOnDisk(t)
+			if _, exists := onDisk["/a"]; exists {
+				t.Error("project /a was not removed")
+			}
+			test.check(t, onDisk)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	EnvXDGConfigHome = "XDG_CONFIG_HOME"
+	EnvXDGCacheHome  = "XDG_CACHE_HOME"
+	EnvXDGStateHome  = "XDG_STATE_HOME"
+
+	cradleDirName = "cradle"
+)
+
+// cradleDirs are the directories cradle keeps its files in.
+type cradleDirs struct {
+	// config holds the project registry, the settings and user templates.
+	config string
+	// cache holds files that can be fetched again, such as cached registry templates.
+	cache string
+	// state holds remembered answers, template versions and trusted hooks.
+	state string
+	// projects is the default directory of new projects.
+	projects string
+}
+
+// singleDir keeps every file in one directory, the layout of CRADLE_HOME and
+// of cradle versions before the XDG base directories.
+func singleDir(dirPath string) cradleDirs {
+	return cradleDirs{config: dirPath, cache: dirPath, state: dirPath, projects: dirPath}
+}
+
+// resolveDirs returns the directories of cradle: everything in CRADLE_HOME
+// when it is set, the XDG base directories otherwise, with projects in ~/cradle.
+func resolveDirs() (cradleDirs, error) {
+	if cradleHomePath := strings.TrimSpace(os.Getenv(EnvCradleHome)); cradleHomePath != "" {
+		return singleDir(cradleHomePath), nil
+	}
+
+	userHomeDir, err := os.UserHomeDir()
+	if err != nil {
+		return cradleDirs{}, err
+	}
+
+	return cradleDirs{
+		config:   xdgDir(EnvXDGConfigHome, userHomeDir, ".config"),
+		cache:    xdgDir(EnvXDGCacheHome, userHomeDir, ".cache"),
+		state:    xdgDir(EnvXDGStateHome, userHomeDir, filepath.Join(".local", "state")),
+		projects: filepath.Join(userHomeDir, cradleDirName),
+	}, nil
+}
+
+// xdgDir returns the cradle directory inside the XDG base directory set in
+// env, or inside its default in the user's home. The specification asks to
+// ignore relative paths.
+func xdgDir(env, userHomeDir, defaultDir string) string {
+	baseDirPath := strings.TrimSpace(os.Getenv(env))
+	if !filepath.IsAbs(baseDirPath) {
+		baseDirPath = filepath.Join(userHomeDir, defaultDir)
+	}
+
+	return filepath.Join(baseDirPath, cradleDirName)
+}
+
+// legacyFiles are the files cradle kept in ~/cradle before the XDG base
+// directories, by the directory they move to. The template files belong to
+// the template package.
+var legacyFiles = []struct {
+	pattern string
+	dir     func(cradleDirs) string
+}{
+	{CradleConfigFileName, func(d cradleDirs) string { return d.config }},
+	{CradleConfigFileName + ".*", func(d cradleDirs) string { return d.config }},
+	{SettingsFileName, func(d cradleDirs) string { return d.config }},
+	{CradleTemplatesDir, func(d cradleDirs) string { return d.config }},
+	{"template_registries.yaml", func(d cradleDirs) string { return d.config }},
+	{"template_cache", func(d cradleDirs) string { return d.cache }},
+	{"template_history.yaml", func(d cradleDirs) string { return d.state }},
+	{"template_versions", func(d cradleDirs) string { return d.state }},
+	{"trusted_hooks.yaml", func(d cradleDirs) string { return d.state }},
+}
+
+// migrateLegacyHome finds the files of an earlier cradle in ~/cradle and
+// offers to move them to the XDG base directories. The projects stay in
+// ~/cradle, which remains the default projects directory. Without a terminal
+// to ask, or when the user declines, ~/cradle keeps being used as before.
+func migrateLegacyHome(dirs cradleDirs) (cradleDirs, error) {
+	legacyDirPath := dirs.projects
+	legacyConfigFilePath := filepath.Join(legacyDirPath, CradleConfigFileName)
+
+	if _, err := os.Stat(legacyConfigFilePath); err != nil {
+		return dirs, nil
+	}
+
+	// Files already in the XDG directories win, the old ones are left alone.
+	if _, err := os.Stat(filepath.Join(dirs.config, CradleConfigFileName)); err == nil {
+		return dirs, nil
+	}
+
+	if !isTerminal() {
+		return singleDir(legacyDirPath), nil
+	}
+
+	fmt.Printf("cradle keeps its files in the XDG base directories now:\n  config  %s\n  cache   %s\n  state   %s\n", dirs.config, dirs.cache, dirs.state)
+	fmt.Printf("Move them out of %s? Projects stay where they are. (Y/N):", legacyDirPath)
+
+	var confirmation string
+	fmt.Scanln(&confirmation)
+
+	if confirmation != "Y" && confirmation != "y" {
+		fmt.Printf("Keeping %s, set %s=%s to stop this question.\n", legacyDirPath, EnvCradleHome, legacyDirPath)
+		return singleDir(legacyDirPath), nil
+	}
+
+	unlock, err := lockConfig(legacyConfigFilePath)
+	if err != nil {
+		return dirs, err
+	}
+	defer func() {
+		unlock()
+		_ = os.Remove(legacyConfigFilePath + ".lock")
+	}()
+
+	for _, file := range legacyFiles {
+		matches, err := filepath.Glob(filepath.Join(legacyDirPath, file.pattern))
+		if err != nil {
+			return dirs, err
+		}
+
+		for _, sourcePath := range matches {
+			if strings.HasSuffix(sourcePath, ".lock") {
+				continue
+			}
+
+			targetPath := filepath.Join(file.dir(dirs), filepath.Base(sourcePath))
+
+			if _, err := os.Stat(targetPath); err == nil {
+				fmt.Println("warning:", targetPath, "already exists, left", sourcePath, "in place")
+				continue
+			}
+
+			err = moveFile(sourcePath, targetPath)
+			if err != nil {
+				return dirs, fmt.Errorf("move %s to %s: %w", sourcePath, targetPath, err)
+			}
+		}
+	}
+
+	fmt.Printf("Moved the files of cradle out of %s\n", legacyDirPath)
+
+	return dirs, nil
+}
+
+// moveFile moves a file or directory, copying it when it cannot be renamed
+// because the target is on another file system.
+func moveFile(sourcePath, targetPath string) error {
+	err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	if os.Rename(sourcePath, targetPath) == nil {
+		return nil
+	}
+
+	err = filepath.WalkDir(sourcePath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(sourcePath, filePath)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		copyPath := filepath.Join(targetPath, relativePath)
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(copyPath, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(copyPath, data, info.Mode().Perm())
+		default:
+			return fmt.Errorf("cannot copy %s, it is not a regular file", filePath)
+		}
+	})
+	if err != nil {
+		_ = os.RemoveAll(targetPath)
+		return err
+	}
+
+	return os.RemoveAll(sourcePath)
+}
+
+// ensureDir creates the directory if it does not exist.
+func ensureDir(dirPath string) error {
+	dirStat, err := os.Stat(dirPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return os.MkdirAll(dirPath, os.ModePerm)
+		}
+		return err
+	}
+
+	if !dirStat.IsDir() {
+		return fmt.Errorf("%s is a file, not a directory", dirPath)
+	}
+
+	return nil
+}
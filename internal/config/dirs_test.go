@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setTestHome makes a new directory the user's home, with neither
+// CRADLE_HOME nor the XDG base directories set.
+func setTestHome(t *testing.T) string {
+	t.Helper()
+
+	homePath := t.TempDir()
+	t.Setenv("HOME", homePath)
+	for _, env := range []string{EnvCradleHome, EnvXDGConfigHome, EnvXDGCacheHome, EnvXDGStateHome} {
+		t.Setenv(env, "")
+	}
+
+	return homePath
+}
+
+func TestResolveDirs(t *testing.T) {
+	homePath := setTestHome(t)
+	basePath := t.TempDir()
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want cradleDirs
+	}{
+		{
+			name: "defaults",
+			want: cradleDirs{
+				config:   filepath.Join(homePath, ".config", "cradle"),
+				cache:    filepath.Join(homePath, ".cache", "cradle"),
+				state:    filepath.Join(homePath, ".local", "state", "cradle"),
+				projects: filepath.Join(homePath, "cradle"),
+			},
+		},
+		{
+			name: "XDG base directories",
+			env: map[string]string{
+				EnvXDGConfigHome: filepath.Join(basePath, "config"),
+				EnvXDGCacheHome:  filepath.Join(basePath, "cache"),
+				EnvXDGStateHome:  filepath.Join(basePath, "state"),
+			},
+			want: cradleDirs{
+				config:   filepath.Join(basePath, "config", "cradle"),
+				cache:    filepath.Join(basePath, "cache", "cradle"),
+				state:    filepath.Join(basePath, "state", "cradle"),
+				projects: filepath.Join(homePath, "cradle"),
+			},
+		},
+		{
+			name: "relative XDG base directory is ignored",
+			env:  map[string]string{EnvXDGConfigHome: "config"},
+			want: cradleDirs{
+				config:   filepath.Join(homePath, ".config", "cradle"),
+				cache:    filepath.Join(homePath, ".cache", "cradle"),
+				state:    filepath.Join(homePath, ".local", "state", "cradle"),
+				projects: filepath.Join(homePath, "cradle"),
+			},
+		},
+		{
+			name: "CRADLE_HOME",
+			env: map[string]string{
+				EnvCradleHome:    filepath.Join(basePath, "home"),
+				EnvXDGConfigHome: filepath.Join(basePath, "config"),
+			},
+			want: singleDir(filepath.Join(basePath, "home")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for env, value := range test.env {
+				t.Setenv(env, value)
+			}
+
+			got, err := resolveDirs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("resolveDirs() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInitFreshInstall(t *testing.T) {
+	homePath := setTestHome(t)
+	setTestTerminal(t, true, "")
+
+	err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := cradleDirs{
+		config: filepath.Join(homePath, ".config", "cradle"),
+		cache:  filepath.Join(homePath, ".cache", "cradle"),
+		state:  filepath.Join(homePath, ".local", "state", "cradle"),
+	}
+	got := cradleDirs{config: instance.ConfigDirPath, cache: instance.CacheDirPath, state: instance.StateDirPath}
+	if got != want {
+		t.Errorf("dirs = %+v, want %+v", got, want)
+	}
+
+	for _, dirPath := range []string{want.config, want.cache, want.state} {
+		if info, err := os.Stat(dirPath); err != nil || !info.IsDir() {
+			t.Errorf("directory %s was not created: %v", dirPath, err)
+		}
+	}
+
+	if instance.CradleConfigFilePath != filepath.Join(want.config, CradleConfigFileName) {
+		t.Errorf("config file = %s, want it in %s", instance.CradleConfigFilePath, want.config)
+	}
+	if instance.Settings.ProjectsDirPath != filepath.Join(homePath, "cradle") {
+		t.Errorf("projects dir = %s, want ~/cradle", instance.Settings.ProjectsDirPath)
+	}
+	if _, err := os.Stat(filepath.Join(homePath, "cradle", CradleConfigFileName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("config file was created in ~/cradle: %v", err)
+	}
+}
+
+func TestInitLegacyHome(t *testing.T) {
+	// The files of an earlier cradle in ~/cradle and the directory they move to.
+	legacyFiles := []struct {
+		name    string
+		content string
+		dir     func(cradleDirs) string
+	}{
+		{CradleConfigFileName, "version: 1\nprojects:\n  - path: /a\n    created_at: 2024-01-01T00:00:00Z\n", func(d cradleDirs) string { return d.config }},
+		{CradleConfigFileName + ".bak.1", "version: 1\nprojects: []\n", func(d cradleDirs) string { return d.config }},
+		{SettingsFileName, "# settings\n", func(d cradleDirs) string { return d.config }},
+		{"templates/app.yaml", "version: v1\nname: app\n", func(d cradleDirs) string { return d.config }},
+		{"template_cache/lib/1.0.0/lib.yaml", "version: v1\nname: lib\n", func(d cradleDirs) string { return d.cache }},
+		{"trusted_hooks.yaml", "app: abc\n", func(d cradleDirs) string { return d.state }},
+		{"template_versions/app/abc/app.yaml", "version: v1\nname: app\n", func(d cradleDirs) string { return d.state }},
+	}
+
+	tests := []struct {
+		name     string
+		terminal bool
+		answer   string
+		wantMove bool
+	}{
+		{name: "confirmed", terminal: true, answer: "y\n", wantMove: true},
+		{name: "declined", terminal: true, answer: "n\n"},
+		{name: "no terminal"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			homePath := setTestHome(t)
+			legacyDirPath := filepath.Join(homePath, "cradle")
+
+			for _, file := range legacyFiles {
+				filePath := filepath.Join(legacyDirPath, filepath.FromSlash(file.name))
+				if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filePath, []byte(file.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			setTestTerminal(t, test.terminal, test.answer)
+
+			err := Init()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, exists := projectsOnDisk(t)["/a"]; !exists {
+				t.Errorf("project /a of the legacy config file is missing")
+			}
+			if instance.Settings.ProjectsDirPath != legacyDirPath {
+				t.Errorf("projects dir = %s, want %s", instance.Settings.ProjectsDirPath, legacyDirPath)
+			}
+
+			xdgDirs, err := resolveDirs()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantDirs := singleDir(legacyDirPath)
+			if test.wantMove {
+				wantDirs = xdgDirs
+			}
+			got := cradleDirs{config: instance.ConfigDirPath, cache: instance.CacheDirPath, state: instance.StateDirPath}
+			if want := (cradleDirs{config: wantDirs.config, cache: wantDirs.cache, state: wantDirs.state}); got != want {
+				t.Errorf("dirs = %+v, want %+v", got, want)
+			}
+
+			for _, file := range legacyFiles {
+				legacyPath := filepath.Join(legacyDirPath, filepath.FromSlash(file.name))
+				movedPath := filepath.Join(file.dir(xdgDirs), filepath.FromSlash(file.name))
+
+				_, legacyErr := os.Stat(legacyPath)
+				_, movedErr := os.Stat(movedPath)
+
+				if test.wantMove && (legacyErr == nil || movedErr != nil) {
+					t.Errorf("%s was not moved to %s", legacyPath, movedPath)
+				}
+				if !test.wantMove && (legacyErr != nil || movedErr == nil) {
+					t.Errorf("%s was moved to %s, want it kept in place", legacyPath, movedPath)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(legacyDirPath, CradleConfigFileName+".lock")); test.wantMove && !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("lock file was left in %s: %v", legacyDirPath, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long a cradle process waits for another one to
+	// finish changing the config file.
+	lockTimeout = 10 * time.Second
+
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// lockConfig takes the advisory lock guarding read-modify-write cycles of the
+// config file and returns the function releasing it. The lock is held on a
+// separate file, cradle.yaml.lock, because the config file itself is replaced
+// on every save.
+func lockConfig(configFilePath string) (func(), error) {
+	lockFile, err := os.OpenFile(configFilePath+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(lockFile)
+		if err != nil {
+			lockFile.Close()
+			return nil, fmt.Errorf("lock %s: %w", lockFile.Name(), err)
+		}
+
+		if locked {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			lockFile.Close()
+			return nil, fmt.Errorf("%s is being changed by another cradle process, gave up waiting after %s", configFilePath, lockTimeout)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+
+	return func() {
+		unlockFile(lockFile)
+		lockFile.Close()
+	}, nil
+}
@@ -0,0 +1,44 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockConfig(t *testing.T) {
+	configFilePath := filepath.Join(t.TempDir(), CradleConfigFileName)
+
+	unlock, err := lockConfig(configFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process opens the lock file on its own.
+	other, err := os.OpenFile(configFilePath+".lock", os.O_RDWR, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	locked, err := tryLockFile(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if locked {
+		t.Fatal("lock was taken while held")
+	}
+
+	unlock()
+
+	locked, err = tryLockFile(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked {
+		t.Error("lock was not released")
+	}
+	unlockFile(other)
+}
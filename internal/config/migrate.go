@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configVersion is the version of the config file format written by this
+// version of cradle. Bump it together with a new entry in migrations whenever
+// the format changes in a way older versions cannot read.
+const configVersion = 1
+
+// migrations upgrade a config file, decoded as a generic YAML document, from
+// the version at their index to the next one.
+var migrations = []func(doc map[string]any) error{
+	// 0: files written before the version key existed, the layout is the same.
+	func(doc map[string]any) error { return nil },
+}
+
+// errNewerConfig is returned for config files written by a newer version of
+// cradle, which this version cannot read without losing data.
+var errNewerConfig = errors.New("it was written by a newer version of cradle")
+
+// migrateConfig parses the content of a config file, upgrading it in memory
+// from older versions of the format.
+func migrateConfig(data []byte) (cradleConfig, error) {
+	var config cradleConfig
+
+	doc, version, err := decodeConfig(data)
+	if err != nil {
+		return config, err
+	}
+
+	if version > configVersion {
+		return config, fmt.Errorf("%w (format version %d, this version reads up to %d), upgrade cradle to use it", errNewerConfig, version, configVersion)
+	}
+
+	if version < configVersion {
+		for ; version < configVersion; version++ {
+			err = migrations[version](doc)
+			if err != nil {
+				return config, fmt.Errorf("migrate from version %d: %w", version, err)
+			}
+		}
+		doc["version"] = configVersion
+
+		data, err = yaml.Marshal(doc)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	err = yaml.Unmarshal(data, &config)
+	return config, err
+}
+
+// decodeConfig decodes a config file as a generic YAML document and returns
+// its format version, 0 for files without one.
+func decodeConfig(data []byte) (map[string]any, int, error) {
+	doc := make(map[string]any)
+
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	raw, exists := doc["version"]
+	if !exists {
+		return doc, 0, nil
+	}
+
+	version, ok := raw.(int)
+	if !ok || version < 0 {
+		return nil, 0, fmt.Errorf("version must be a non-negative integer, got %v", raw)
+	}
+
+	return doc, version, nil
+}
+
+// migrateConfigFile rewrites a config file of an older format version in the
+// current one, keeping the original as cradle.yaml.v<version>. Files that
+// cannot be read are left alone for parseCradleConfigFile to report.
+func migrateConfigFile(configFilePath string) error {
+	if !needsMigration(configFilePath) {
+		return nil
+	}
+
+	unlock, err := lockConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another cradle process may have migrated the file in the meantime.
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return err
+	}
+
+	doc, version, err := decodeConfig(data)
+	if err != nil || version >= configVersion || len(doc) == 0 {
+		return nil
+	}
+
+	projects, err := parseConfig(data)
+	if err != nil {
+		return nil
+	}
+
+	originalPath := fmt.Sprintf("%s.v%d", configFilePath, version)
+	if _, err := os.Stat(originalPath); errors.Is(err, os.ErrNotExist) {
+		err = WriteFileAtomic(originalPath, data, 0o644)
+		if err != nil {
+			return fmt.Errorf("back up %s before migrating it: %w", configFilePath, err)
+		}
+	}
+
+	fileBytes, err := marshalConfig(projects)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(configFilePath, fileBytes, 0o644)
+}
+
+// needsMigration reports whether the config file holds projects in an older
+// format version.
+func needsMigration(configFilePath string) bool {
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return false
+	}
+
+	doc, version, err := decodeConfig(data)
+	return err == nil && version < configVersion && len(doc) > 0
+}
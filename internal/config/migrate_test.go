@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	const projects = "projects:\n  - path: /a\n    created_at: 2024-01-01T00:00:00Z\n"
+
+	tests := []struct {
+		name string
+		data string
+		// wantPaths are the projects read, wantErr is part of the error otherwise.
+		wantPaths []string
+		wantErr   string
+	}{
+		{
+			name:      "without version",
+			data:      projects,
+			wantPaths: []string{"/a"},
+		},
+		{
+			name:      "version 0",
+			data:      "version: 0\n" + projects,
+			wantPaths: []string{"/a"},
+		},
+		{
+			name:      "current version",
+			data:      fmt.Sprintf("version: %d\n", configVersion) + projects,
+			wantPaths: []string{"/a"},
+		},
+		{
+			name:    "newer version",
+			data:    fmt.Sprintf("version: %d\n", configVersion+1) + projects,
+			wantErr: errNewerConfig.Error(),
+		},
+		{
+			name:    "text version",
+			data:    "version: one\n" + projects,
+			wantErr: "version must be a non-negative integer",
+		},
+		{
+			name:    "fractional version",
+			data:    "version: 1.5\n" + projects,
+			wantErr: "version must be a non-negative integer",
+		},
+		{
+			name:    "negative version",
+			data:    "version: -1\n" + projects,
+			wantErr: "version must be a non-negative integer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := migrateConfig([]byte(test.data))
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("migrateConfig() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, project := range config.Projects {
+				got = append(got, project.Path)
+			}
+			if !slices.Equal(got, test.wantPaths) {
+				t.Errorf("projects = %v, want %v", got, test.wantPaths)
+			}
+			if config.Version != configVersion {
+				t.Errorf("version = %d, want %d", config.Version, configVersion)
+			}
+		})
+	}
+}
+
+func TestInitMigratesConfigFile(t *testing.T) {
+	initTestConfig(t)
+
+	original := "projects:\n  - path: /a\n    created_at: 2024-01-01T00:00:00Z\n"
+
+	err := os.WriteFile(instance.CradleConfigFilePath, []byte(original), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(instance.CradleConfigFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("version: %d\n", configVersion); !strings.Contains(string(data), want) {
+		t.Errorf("migrated file =\n%s\nwant it to contain %q", data, want)
+	}
+	if _, exists := projectsOnDisk(t)["/a"]; !exists {
+		t.Errorf("migrated file lost project /a:\n%s", data)
+	}
+
+	kept, err := os.ReadFile(instance.CradleConfigFilePath + ".v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(kept) != original {
+		t.Errorf("original kept as %q, want %q", kept, original)
+	}
+}
+
+func TestInitRefusesNewerConfigFile(t *testing.T) {
+	initTestConfig(t)
+
+	configFilePath := instance.CradleConfigFilePath
+	newer := fmt.Sprintf("version: %d\nprojects: []\n", configVersion+1)
+
+	err := os.WriteFile(configFilePath, []byte(newer), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Init()
+	if !errors.Is(err, errNewerConfig) {
+		t.Errorf("Init() error = %v, want %v", err, errNewerConfig)
+	}
+
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != newer {
+		t.Errorf("config file = %q, want it to be left alone", data)
+	}
+}
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SettingsFileName = "settings.yaml"
+
+	settingsFileHeader = "Cradle settings, run `cradle config list` to see every setting."
+)
+
+var (
+	// ThemeNames are the color themes of the TUI.
+	ThemeNames = []string{"orange", "blue", "green", "purple", "mono"}
+	// ListColumnNames are the columns list can show.
+	ListColumnNames = []string{"name", "path", "temporary", "created", "template"}
+)
+
+// Settings are the user preferences read from settings.yaml, each one
+// overridden by its CRADLE_<KEY> environment variable.
+type Settings struct {
+	// DefaultTemplate is the template create uses when --template is not given.
+	DefaultTemplate string
+	// Editor is the command opening projects and the settings file.
+	Editor string
+	// ProjectsDirPath is the directory create puts new projects in.
+	ProjectsDirPath string
+	// CleanupTTL is the age temporary projects must reach before cleanup
+	// removes them, 0 removes all of them.
+	CleanupTTL time.Duration
+	// Theme is one of ThemeNames.
+	Theme string
+	// ListColumns are the columns list shows, from ListColumnNames.
+	ListColumns []string
+}
+
+// SettingDefinition describes a key of the settings file.
+type SettingDefinition struct {
+	Key   string
+	Usage string
+	// Default describes the value used when the setting is not set.
+	Default string
+	apply   func(settings *Settings, value string) error
+}
+
+// EnvVar returns the environment variable overriding the setting.
+func (d SettingDefinition) EnvVar() string {
+	return "CRADLE_" + strings.ToUpper(d.Key)
+}
+
+var settingDefinitions = []SettingDefinition{
+	{
+		Key:     "default_template",
+		Usage:   "template create uses when --template is not given",
+		Default: "no template",
+		apply: func(settings *Settings, value string) error {
+			settings.DefaultTemplate = value
+			return nil
+		},
+	},
+	{
+		Key:     "editor",
+		Usage:   "command opening projects with open --edit and this file with config edit",
+		Default: "$VISUAL, $EDITOR or " + fallbackEditor(),
+		apply: func(settings *Settings, value string) error {
+			settings.Editor = value
+			return nil
+		},
+	},
+	{
+		Key:     "projects_dir",
+		Usage:   "directory create puts new projects in",
+		Default: "~/cradle, or CRADLE_HOME when it is set",
+		apply: func(settings *Settings, value string) error {
+			dirPath, err := expandPath(value)
+			if err != nil {
+				return err
+			}
+			settings.ProjectsDirPath = dirPath
+			return nil
+		},
+	},
+	{
+		Key:     "cleanup_ttl",
+		Usage:   "age temporary projects must reach before cleanup removes them, e.g. 12h or 7d",
+		Default: "0, remove all",
+		apply: func(settings *Settings, value string) error {
+			ttl, err := ParseAge(value)
+			if err != nil {
+				return err
+			}
+			settings.CleanupTTL = ttl
+			return nil
+		},
+	},
+	{
+		Key:     "theme",
+		Usage:   "color theme of the TUI: " + strings.Join(ThemeNames, ", "),
+		Default: ThemeNames[0],
+		apply: func(settings *Settings, value string) error {
+			if !slices.Contains(ThemeNames, value) {
+				return fmt.Errorf("unknown theme %q, use one of %s", value, strings.Join(ThemeNames, ", "))
+			}
+			settings.Theme = value
+			return nil
+		},
+	},
+	{
+		Key:     "list_columns",
+		Usage:   "comma separated columns list shows: " + strings.Join(ListColumnNames, ", "),
+		Default: "name,path,temporary,created",
+		apply: func(settings *Settings, value string) error {
+			columns, err := ParseListColumns(value)
+			if err != nil {
+				return err
+			}
+			settings.ListColumns = columns
+			return nil
+		},
+	},
+}
+
+// SettingDefinitions returns the keys of the settings file.
+func SettingDefinitions() []SettingDefinition {
+	return slices.Clone(settingDefinitions)
+}
+
+// SettingValue is the effective value of a setting.
+type SettingValue struct {
+	SettingDefinition
+	// Value is empty when the setting is not set.
+	Value string
+	// Source is the settings file or environment variable the value comes
+	// from, empty when the setting is not set.
+	Source string
+}
+
+// LookupSetting returns the effective value of the setting with the key.
+func LookupSetting(key string) (SettingValue, error) {
+	definition, err := settingDefinition(key)
+	if err != nil {
+		return SettingValue{}, err
+	}
+
+	fileValues, err := readSettingsFile(instance.SettingsFilePath)
+	if err != nil {
+		return SettingValue{}, err
+	}
+
+	return lookupSetting(definition, fileValues), nil
+}
+
+// ListSettings returns the effective value of every setting.
+func ListSettings() ([]SettingValue, error) {
+	fileValues, err := readSettingsFile(instance.SettingsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]SettingValue, 0, len(settingDefinitions))
+	for _, definition := range settingDefinitions {
+		values = append(values, lookupSetting(definition, fileValues))
+	}
+
+	return values, nil
+}
+
+// SetSetting validates the value and writes it to the settings file,
+// keeping the comments and the other settings of the file.
+func SetSetting(key, value string) error {
+	definition, err := settingDefinition(key)
+	if err != nil {
+		return err
+	}
+
+	var settings Settings
+	err = definition.apply(&settings, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return editSettingsFile(func(mapping *yaml.Node) bool {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				mapping.Content[i+1].SetString(value)
+				return true
+			}
+		}
+
+		keyNode := &yaml.Node{}
+		keyNode.SetString(key)
+		valueNode := &yaml.Node{}
+		valueNode.SetString(value)
+		mapping.Content = append(mapping.Content, keyNode, valueNode)
+
+		return true
+	})
+}
+
+// UnsetSetting removes the setting from the settings file and reports whether it was set.
+func UnsetSetting(key string) (bool, error) {
+	if _, err := settingDefinition(key); err != nil {
+		return false, err
+	}
+
+	removed := false
+	err := editSettingsFile(func(mapping *yaml.Node) bool {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				mapping.Content = slices.Delete(mapping.Content, i, i+2)
+				removed = true
+				return true
+			}
+		}
+		return false
+	})
+
+	return removed, err
+}
+
+// EnsureSettingsFile creates an empty settings file if there is none and returns its path.
+func EnsureSettingsFile() (string, error) {
+	_, err := os.Stat(instance.SettingsFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return instance.SettingsFilePath, WriteFileAtomic(instance.SettingsFilePath, []byte("# "+settingsFileHeader+"\n"), 0o644)
+	}
+
+	return instance.SettingsFilePath, err
+}
+
+// CheckSettings reads the settings again, as Init does, and returns the
+// problems found in them.
+func CheckSettings() []error {
+	_, problems := loadSettings(instance.SettingsFilePath, instance.defaultProjectsDirPath)
+	return problems
+}
+
+// ParseAge parses a duration such as 90m or 12h, also accepting days as 7d.
+func ParseAge(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, use a duration such as 12h or 7d", value)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q, use a duration such as 12h or 7d", value)
+	}
+
+	return age, nil
+}
+
+// ParseListColumns parses a comma separated list of ListColumnNames.
+func ParseListColumns(value string) ([]string, error) {
+	var columns []string
+	for column := range strings.SplitSeq(value, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" {
+			continue
+		}
+		if !slices.Contains(ListColumnNames, column) {
+			return nil, fmt.Errorf("unknown column %q, use %s", column, strings.Join(ListColumnNames, ", "))
+		}
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given, use %s", strings.Join(ListColumnNames, ", "))
+	}
+
+	return columns, nil
+}
+
+// loadSettings resolves the settings from their defaults, the settings file
+// and the environment. Invalid values are reported as problems and replaced
+// by the default, so that a broken file never keeps cradle from starting.
+func loadSettings(settingsFilePath, defaultProjectsDirPath string) (Settings, []error) {
+	settings := Settings{
+		Editor:          defaultEditor(),
+		ProjectsDirPath: defaultProjectsDirPath,
+		Theme:           ThemeNames[0],
+		ListColumns:     []string{"name", "path", "temporary", "created"},
+	}
+
+	var problems []error
+
+	fileValues, err := readSettingsFile(settingsFilePath)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	for key := range fileValues {
+		if _, err := settingDefinition(key); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", settingsFilePath, err))
+		}
+	}
+
+	for _, definition := range settingDefinitions {
+		value := lookupSetting(definition, fileValues)
+		if value.Source == "" {
+			continue
+		}
+
+		err := definition.apply(&settings, value.Value)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s in %s: %w, using the default", definition.Key, value.Source, err))
+		}
+	}
+
+	return settings, problems
+}
+
+// lookupSetting returns the value of the setting, the environment taking
+// precedence over the settings file.
+func lookupSetting(definition SettingDefinition, fileValues map[string]string) SettingValue {
+	if value := strings.TrimSpace(os.Getenv(definition.EnvVar())); value != "" {
+		return SettingValue{SettingDefinition: definition, Value: value, Source: definition.EnvVar()}
+	}
+
+	if value, exists := fileValues[definition.Key]; exists {
+		return SettingValue{SettingDefinition: definition, Value: value, Source: SettingsFileName}
+	}
+
+	return SettingValue{SettingDefinition: definition}
+}
+
+func settingDefinition(key string) (SettingDefinition, error) {
+	for _, definition := range settingDefinitions {
+		if definition.Key == key {
+			return definition, nil
+		}
+	}
+
+	return SettingDefinition{}, fmt.Errorf("unknown setting %q, run `cradle config list` to see every setting", key)
+}
+
+// readSettingsFile reads the settings file as strings by key. Lists are
+// joined with commas, so list_columns can be written either way.
+func readSettingsFile(settingsFilePath string) (map[string]string, error) {
+	data, err := os.ReadFile(settingsFilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var raw map[string]any
+	err = yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", settingsFilePath, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value := value.(type) {
+		case nil:
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return values, nil
+}
+
+// editSettingsFile applies edit to the top level mapping of the settings file
+// and saves the file when edit reports a change.
+func editSettingsFile(edit func(mapping *yaml.Node) bool) error {
+	data, err := os.ReadFile(instance.SettingsFilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", instance.SettingsFilePath, err)
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, HeadComment: settingsFileHeader}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s must map setting keys to values", instance.SettingsFilePath)
+	}
+
+	if !edit(mapping) {
+		return nil
+	}
+
+	data, err = yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+
+	err = WriteFileAtomic(instance.SettingsFilePath, data, 0o644)
+	if err != nil {
+		return err
+	}
+
+	instance.Settings, _ = loadSettings(instance.SettingsFilePath, instance.defaultProjectsDirPath)
+
+	return nil
+}
+
+// expandPath makes a directory path absolute, expanding a leading ~.
+func expandPath(value string) (string, error) {
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		userHomeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		value = filepath.Join(userHomeDir, value[1:])
+	}
+
+	return filepath.Abs(value)
+}
+
+func defaultEditor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+	return fallbackEditor()
+}
+
+func fallbackEditor() string {
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetAndUnsetSetting(t *testing.T) {
+	initTestConfig(t)
+
+	err := os.WriteFile(instance.SettingsFilePath, []byte("# my settings\ntheme: blue # the color\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, value := range map[string]string{"theme": "green", "cleanup_ttl": "7d"} {
+		err = SetSetting(key, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if instance.Settings.Theme != "green" || instance.Settings.CleanupTTL != 7*24*time.Hour {
+		t.Errorf("settings = %+v, want the green theme and a cleanup ttl of 7 days", instance.Settings)
+	}
+
+	data, err := os.ReadFile(instance.SettingsFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# my settings", "theme: green # the color", "cleanup_ttl: 7d"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("settings file =\n%s\nwant it to contain %q", data, want)
+		}
+	}
+
+	err = SetSetting("theme", "pink")
+	if err == nil || !strings.Contains(err.Error(), `unknown theme "pink"`) {
+		t.Errorf("SetSetting() error = %v, want the theme to be refused", err)
+	}
+	err = SetSetting("colour", "green")
+	if err == nil || !strings.Contains(err.Error(), `unknown setting "colour"`) {
+		t.Errorf("SetSetting() error = %v, want the key to be refused", err)
+	}
+
+	for _, wantRemoved := range []bool{true, false} {
+		removed, err := UnsetSetting("theme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if removed != wantRemoved {
+			t.Errorf("UnsetSetting() = %v, want %v", removed, wantRemoved)
+		}
+	}
+
+	if instance.Settings.Theme != ThemeNames[0] {
+		t.Errorf("theme = %q, want the default %q", instance.Settings.Theme, ThemeNames[0])
+	}
+
+	value, err := LookupSetting("cleanup_ttl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Value != "7d" || value.Source != SettingsFileName {
+		t.Errorf("cleanup_ttl = %q from %q, want 7d from %s", value.Value, value.Source, SettingsFileName)
+	}
+}
+
+func TestSettingsEnvironmentOverrides(t *testing.T) {
+	homePath := t.TempDir()
+	t.Setenv("HOME", homePath)
+	t.Setenv("CRADLE_THEME", "purple")
+	t.Setenv("CRADLE_PROJECTS_DIR", "~/work")
+	// Invalid values fall back to the default.
+	t.Setenv("CRADLE_CLEANUP_TTL", "soon")
+
+	initTestConfig(t)
+
+	err := os.WriteFile(instance.SettingsFilePath, []byte("theme: blue\ncleanup_ttl: 12h\nlist_columns: [name, template]\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := instance.Settings
+	if settings.Theme != "purple" {
+		t.Errorf("theme = %q, want purple from the environment", settings.Theme)
+	}
+	if want := filepath.Join(homePath, "work"); settings.ProjectsDirPath != want {
+		t.Errorf("projects dir = %q, want %q", settings.ProjectsDirPath, want)
+	}
+	if settings.CleanupTTL != 0 {
+		t.Errorf("cleanup ttl = %s, want the default", settings.CleanupTTL)
+	}
+	if want := []string{"name", "template"}; !slices.Equal(settings.ListColumns, want) {
+		t.Errorf("list columns = %v, want %v", settings.ListColumns, want)
+	}
+
+	value, err := LookupSetting("theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Value != "purple" || value.Source != "CRADLE_THEME" {
+		t.Errorf("theme = %q from %q, want purple from CRADLE_THEME", value.Value, value.Source)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "90m", want: 90 * time.Minute},
+		{value: "12h", want: 12 * time.Hour},
+		{value: "7d", want: 7 * 24 * time.Hour},
+		{value: "0.5d", want: 12 * time.Hour},
+		{value: "-1h", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "week", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseAge(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseAge() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("ParseAge() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package diff compares text line by line.
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kind is the kind of an edit.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is a line kept, deleted from the old text or inserted from the new text.
+type Edit struct {
+	Kind Kind
+	// Line includes its line ending, the last line of a text may not have one.
+	Line string
+}
+
+// maxEditDistance bounds the work spent on very different texts, beyond it
+// the whole old text is replaced with the new one.
+const maxEditDistance = 4096
+
+// SplitLines splits text into lines, keeping the line endings.
+func SplitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Lines returns the edits turning the lines of a into the lines of b.
+func Lines(a, b string) []Edit {
+	return Diff(SplitLines(a), SplitLines(b))
+}
+
+// Diff returns a shortest list of edits turning a into b, using Myers' algorithm.
+func Diff(a, b []string) []Edit {
+	// Lines shared at the start and end need no search.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+
+	return edits
+}
+
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds the furthest reaching x of every diagonal -d..d before round d.
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	return replaceAll(a, b)
+}
+
+// backtrack walks the trace back from the end of both texts to collect the edits.
+func backtrack(trace [][]int, a, b []string) []Edit {
+	var edits []Edit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// The saved diagonals of round d cover k = -d..d, stored from index 0.
+		at := func(k int) int {
+			if k < -d || k > d {
+				return 0
+			}
+			return trace[d][k+d]
+		}
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, Edit{Insert, b[y-1]})
+			} else {
+				edits = append(edits, Edit{Delete, a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(edits)
+	return edits
+}
+
+func replaceAll(a, b []string) []Edit {
+	var edits []Edit
+	for _, line := range a {
+		edits = append(edits, Edit{Delete, line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Insert, line})
+	}
+	return edits
+}
+
+// Hunk is a group of nearby edits with the unchanged lines around them.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Edits              []Edit
+}
+
+// Header returns the unified diff header of the hunk, e.g. "@@ -1,3 +1,4 @@".
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// Hunks groups the changes of edits into hunks with up to context unchanged
+// lines around them. Changes closer than twice the context share a hunk.
+func Hunks(edits []Edit, context int) []Hunk {
+	// Line numbers in the old and new text before each edit.
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	for i, edit := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if edit.Kind != Insert {
+			oldLine[i+1]++
+		}
+		if edit.Kind != Delete {
+			newLine[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	previousEnd := 0
+
+	for i := 0; i < len(edits); i++ {
+		if edits[i].Kind == Equal {
+			continue
+		}
+
+		start := max(i-context, previousEnd)
+
+		last := i
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].Kind != Equal {
+				last = j
+				continue
+			}
+
+			equalEnd := j
+			for equalEnd < len(edits) && edits[equalEnd].Kind == Equal {
+				equalEnd++
+			}
+			if equalEnd == len(edits) || equalEnd-j > 2*context {
+				break
+			}
+			j = equalEnd - 1
+		}
+
+		end := min(last+1+context, len(edits))
+
+		hunk := Hunk{
+			OldStart: oldLine[start] + 1,
+			OldLines: oldLine[end] - oldLine[start],
+			NewStart: newLine[start] + 1,
+			NewLines: newLine[end] - newLine[start],
+			Edits:    edits[start:end],
+		}
+		// An empty range starts at the line before it.
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+
+		hunks = append(hunks, hunk)
+		previousEnd = end
+		i = end - 1
+	}
+
+	return hunks
+}
+
+// Unified returns the changes from a to b in unified diff format with three
+// lines of context, or an empty string when the texts are equal.
+func Unified(oldName, newName, a, b string) string {
+	hunks := Hunks(Lines(a, b), 3)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, hunk := range hunks {
+		out.WriteString(hunk.Header() + "\n")
+
+		for _, edit := range hunk.Edits {
+			switch edit.Kind {
+			case Equal:
+				out.WriteString(" ")
+			case Delete:
+				out.WriteString("-")
+			case Insert:
+				out.WriteString("+")
+			}
+
+			out.WriteString(edit.Line)
+			if !strings.HasSuffix(edit.Line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return out.String()
+}
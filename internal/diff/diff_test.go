@@ -0,0 +1,295 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// formatEdits writes edits one per line, prefixed with " ", "-" or "+" like
+// a unified diff, marking a line without line ending with a trailing "$".
+func formatEdits(edits []Edit) string {
+	var out strings.Builder
+	for _, edit := range edits {
+		switch edit.Kind {
+		case Equal:
+			out.WriteString(" ")
+		case Delete:
+			out.WriteString("-")
+		case Insert:
+			out.WriteString("+")
+		}
+
+		line, hasLineEnding := strings.CutSuffix(edit.Line, "\n")
+		out.WriteString(line)
+		if !hasLineEnding {
+			out.WriteString("$")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: " a\n b\n",
+		},
+		{
+			name: "both empty",
+			a:    "",
+			b:    "",
+			want: "",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\nb\n",
+			want: "+a\n+b\n",
+		},
+		{
+			name: "to empty",
+			a:    "a\nb\n",
+			b:    "",
+			want: "-a\n-b\n",
+		},
+		{
+			name: "insert at start",
+			a:    "b\nc\n",
+			b:    "a\nb\nc\n",
+			want: "+a\n b\n c\n",
+		},
+		{
+			name: "insert in middle",
+			a:    "a\nc\n",
+			b:    "a\nb\nc\n",
+			want: " a\n+b\n c\n",
+		},
+		{
+			name: "insert at end",
+			a:    "a\nb\n",
+			b:    "a\nb\nc\n",
+			want: " a\n b\n+c\n",
+		},
+		{
+			name: "delete at start",
+			a:    "a\nb\nc\n",
+			b:    "b\nc\n",
+			want: "-a\n b\n c\n",
+		},
+		{
+			name: "delete in middle",
+			a:    "a\nb\nc\n",
+			b:    "a\nc\n",
+			want: " a\n-b\n c\n",
+		},
+		{
+			name: "delete at end",
+			a:    "a\nb\nc\n",
+			b:    "a\nb\n",
+			want: " a\n b\n-c\n",
+		},
+		{
+			name: "replace at start",
+			a:    "a\nb\nc\n",
+			b:    "x\nb\nc\n",
+			want: "-a\n+x\n b\n c\n",
+		},
+		{
+			name: "replace in middle",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			want: " a\n-b\n+x\n c\n",
+		},
+		{
+			name: "replace at end",
+			a:    "a\nb\nc\n",
+			b:    "a\nb\nx\n",
+			want: " a\n b\n-c\n+x\n",
+		},
+		{
+			name: "changes around equal lines",
+			a:    "a\nb\nc\nd\ne\n",
+			b:    "b\nc\nx\nd\n",
+			want: "-a\n b\n c\n+x\n d\n-e\n",
+		},
+		{
+			name: "final newline removed",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: " a\n-b\n+b$\n",
+		},
+		{
+			name: "final newline added",
+			a:    "a\nb",
+			b:    "a\nb\n",
+			want: " a\n-b$\n+b\n",
+		},
+		{
+			name: "append to line without final newline",
+			a:    "a\nb",
+			b:    "a\nb\nc",
+			want: " a\n-b$\n+b\n+c$\n",
+		},
+		{
+			name: "both without final newline",
+			a:    "a\nb",
+			b:    "x\nb",
+			want: "-a\n+x\n b$\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edits := Lines(test.a, test.b)
+
+			if got := formatEdits(edits); got != test.want {
+				t.Errorf("Lines(%q, %q) =\n%s\nwant\n%s", test.a, test.b, got, test.want)
+			}
+
+			// The edits must turn a into b.
+			var oldText, newText strings.Builder
+			for _, edit := range edits {
+				if edit.Kind != Insert {
+					oldText.WriteString(edit.Line)
+				}
+				if edit.Kind != Delete {
+					newText.WriteString(edit.Line)
+				}
+			}
+			if oldText.String() != test.a || newText.String() != test.b {
+				t.Errorf("edits turn %q into %q, want %q into %q", oldText.String(), newText.String(), test.a, test.b)
+			}
+		})
+	}
+}
+
+func TestDiffIsShortest(t *testing.T) {
+	a := SplitLines("a\nb\nc\na\nb\nb\na\n")
+	b := SplitLines("c\nb\na\nb\na\nc\n")
+
+	changes := 0
+	for _, edit := range Diff(a, b) {
+		if edit.Kind != Equal {
+			changes++
+		}
+	}
+
+	// The example of Myers' paper, its shortest edit script has 5 edits.
+	if changes != 5 {
+		t.Errorf("Diff() has %d changes, want 5", changes)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    []string
+	}{
+		{
+			name:    "equal",
+			a:       "a\nb\n",
+			b:       "a\nb\n",
+			context: 3,
+			want:    nil,
+		},
+		{
+			name:    "change at start",
+			a:       "a\nb\nc\nd\n",
+			b:       "x\nb\nc\nd\n",
+			context: 1,
+			want:    []string{"@@ -1,2 +1,2 @@"},
+		},
+		{
+			name:    "change at end",
+			a:       "a\nb\nc\nd\n",
+			b:       "a\nb\nc\nx\n",
+			context: 1,
+			want:    []string{"@@ -3,2 +3,2 @@"},
+		},
+		{
+			name:    "insert into empty",
+			a:       "",
+			b:       "a\n",
+			context: 3,
+			want:    []string{"@@ -0,0 +1,1 @@"},
+		},
+		{
+			name:    "delete everything",
+			a:       "a\n",
+			b:       "",
+			context: 3,
+			want:    []string{"@@ -1,1 +0,0 @@"},
+		},
+		{
+			name:    "close changes share a hunk",
+			a:       "a\nb\nc\nd\ne\n",
+			b:       "x\nb\nc\nd\ny\n",
+			context: 2,
+			want:    []string{"@@ -1,5 +1,5 @@"},
+		},
+		{
+			name:    "distant changes are split",
+			a:       "a\nb\nc\nd\ne\nf\ng\n",
+			b:       "x\nb\nc\nd\ne\nf\ny\n",
+			context: 1,
+			want:    []string{"@@ -1,2 +1,2 @@", "@@ -6,2 +6,2 @@"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, hunk := range Hunks(Lines(test.a, test.b), test.context) {
+				got = append(got, hunk.Header())
+			}
+
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Errorf("Hunks() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\n",
+			b:    "a\n",
+			want: "",
+		},
+		{
+			name: "replace",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "missing final newline",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Unified("old", "new", test.a, test.b); got != test.want {
+				t.Errorf("Unified() =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package diff
+
+import "strings"
+
+// Conflict markers written around lines changed differently on both sides.
+const (
+	MarkerOurs      = "<<<<<<<"
+	MarkerSeparator = "======="
+	MarkerTheirs    = ">>>>>>>"
+)
+
+// chunk replaces the base lines [start, end) with lines.
+type chunk struct {
+	start, end int
+	lines      []string
+}
+
+// chunks collects the changes of edits as replacements of base line ranges.
+func chunks(edits []Edit) []chunk {
+	var result []chunk
+	var current *chunk
+	baseLine := 0
+
+	for _, edit := range edits {
+		if edit.Kind == Equal {
+			if current != nil {
+				result = append(result, *current)
+				current = nil
+			}
+			baseLine++
+			continue
+		}
+
+		if current == nil {
+			current = &chunk{start: baseLine, end: baseLine}
+		}
+
+		if edit.Kind == Delete {
+			baseLine++
+			current.end = baseLine
+		} else {
+			current.lines = append(current.lines, edit.Line)
+		}
+	}
+
+	if current != nil {
+		result = append(result, *current)
+	}
+
+	return result
+}
+
+// MergeResult is the outcome of a three-way merge.
+type MergeResult struct {
+	Text string
+	// Conflicts is the number of places changed differently on both sides,
+	// each is wrapped in conflict markers in Text.
+	Conflicts int
+}
+
+// Merge combines the changes made to base in ours and in theirs. Changes
+// touching the same or adjacent lines conflict unless they are identical.
+// oursName and theirsName label the sides in conflict markers.
+func Merge(base, ours, theirs, oursName, theirsName string) MergeResult {
+	baseLines := SplitLines(base)
+	oursChunks := chunks(Diff(baseLines, SplitLines(ours)))
+	theirsChunks := chunks(Diff(baseLines, SplitLines(theirs)))
+
+	var out strings.Builder
+	var result MergeResult
+	position := 0
+
+	i, j := 0, 0
+	for i < len(oursChunks) || j < len(theirsChunks) {
+		var oursGroup, theirsGroup []chunk
+
+		// Start a group with the chunk coming first, then pull in every chunk
+		// of either side overlapping or touching the group.
+		var lo, hi int
+		if j == len(theirsChunks) || (i < len(oursChunks) && oursChunks[i].start <= theirsChunks[j].start) {
+			lo, hi = oursChunks[i].start, oursChunks[i].end
+		} else {
+			lo, hi = theirsChunks[j].start, theirsChunks[j].end
+		}
+
+		for grown := true; grown; {
+			grown = false
+			if i < len(oursChunks) && oursChunks[i].start <= hi {
+				oursGroup = append(oursGroup, oursChunks[i])
+				hi = max(hi, oursChunks[i].end)
+				i++
+				grown = true
+			}
+			if j < len(theirsChunks) && theirsChunks[j].start <= hi {
+				theirsGroup = append(theirsGroup, theirsChunks[j])
+				hi = max(hi, theirsChunks[j].end)
+				j++
+				grown = true
+			}
+		}
+
+		writeLines(&out, baseLines[position:lo])
+		position = hi
+
+		oursText := applyChunks(baseLines, lo, hi, oursGroup)
+		theirsText := applyChunks(baseLines, lo, hi, theirsGroup)
+
+		switch {
+		case len(theirsGroup) == 0 || oursText == theirsText:
+			out.WriteString(oursText)
+		case len(oursGroup) == 0:
+			out.WriteString(theirsText)
+		default:
+			result.Conflicts++
+			out.WriteString(MarkerOurs + " " + oursName + "\n")
+			writeSide(&out, oursText)
+			out.WriteString(MarkerSeparator + "\n")
+			writeSide(&out, theirsText)
+			out.WriteString(MarkerTheirs + " " + theirsName + "\n")
+		}
+	}
+
+	writeLines(&out, baseLines[position:])
+
+	result.Text = out.String()
+	return result
+}
+
+// applyChunks returns the base lines [lo, hi) with the chunks applied.
+func applyChunks(baseLines []string, lo, hi int, group []chunk) string {
+	var out strings.Builder
+	position := lo
+
+	for _, c := range group {
+		writeLines(&out, baseLines[position:c.start])
+		writeLines(&out, c.lines)
+		position = c.end
+	}
+	writeLines(&out, baseLines[position:hi])
+
+	return out.String()
+}
+
+func writeLines(out *strings.Builder, lines []string) {
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+}
+
+// writeSide writes one side of a conflict, ending it with a line break so
+// the following marker starts on its own line.
+func writeSide(out *strings.Builder, text string) {
+	out.WriteString(text)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,155 @@
+package diff
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	const base = "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name          string
+		base          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name:   "no changes",
+			base:   base,
+			ours:   base,
+			theirs: base,
+			want:   base,
+		},
+		{
+			name:   "ours only",
+			base:   base,
+			ours:   "a\nB\nc\nd\ne\n",
+			theirs: base,
+			want:   "a\nB\nc\nd\ne\n",
+		},
+		{
+			name:   "theirs only",
+			base:   base,
+			ours:   base,
+			theirs: "a\nb\nc\nD\ne\n",
+			want:   "a\nb\nc\nD\ne\n",
+		},
+		{
+			name:   "theirs inserts at start and end",
+			base:   base,
+			ours:   base,
+			theirs: "0\na\nb\nc\nd\ne\nf\n",
+			want:   "0\na\nb\nc\nd\ne\nf\n",
+		},
+		{
+			name:   "ours deletes",
+			base:   base,
+			ours:   "a\nc\nd\ne\n",
+			theirs: base,
+			want:   "a\nc\nd\ne\n",
+		},
+		{
+			name:   "separate changes on both sides",
+			base:   base,
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "identical changes on both sides",
+			base:   base,
+			ours:   "a\nb\nC\nd\ne\n",
+			theirs: "a\nb\nC\nd\ne\n",
+			want:   "a\nb\nC\nd\ne\n",
+		},
+		{
+			name:   "identical insertions at end",
+			base:   base,
+			ours:   base + "f\n",
+			theirs: base + "f\n",
+			want:   base + "f\n",
+		},
+		{
+			name:          "same line changed differently",
+			base:          base,
+			ours:          "a\nb\nOURS\nd\ne\n",
+			theirs:        "a\nb\nTHEIRS\nd\ne\n",
+			want:          "a\nb\n<<<<<<< ours\nOURS\n=======\nTHEIRS\n>>>>>>> theirs\nd\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "overlapping ranges",
+			base:          base,
+			ours:          "a\nX\nY\nd\ne\n",
+			theirs:        "a\nb\nZ\nW\ne\n",
+			want:          "a\n<<<<<<< ours\nX\nY\nd\n=======\nb\nZ\nW\n>>>>>>> theirs\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "adjacent changes",
+			base:          base,
+			ours:          "a\nB\nc\nd\ne\n",
+			theirs:        "a\nb\nC\nd\ne\n",
+			want:          "a\n<<<<<<< ours\nB\nc\n=======\nb\nC\n>>>>>>> theirs\nd\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "deleted on one side, changed on the other",
+			base:          base,
+			ours:          "a\nb\nd\ne\n",
+			theirs:        "a\nb\nC\nd\ne\n",
+			want:          "a\nb\n<<<<<<< ours\n=======\nC\n>>>>>>> theirs\nd\ne\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "different insertions at end",
+			base:          base,
+			ours:          base + "f\n",
+			theirs:        base + "g\n",
+			want:          base + "<<<<<<< ours\nf\n=======\ng\n>>>>>>> theirs\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "two conflicts",
+			base:          base,
+			ours:          "A1\nb\nc\nd\nE1\n",
+			theirs:        "A2\nb\nc\nd\nE2\n",
+			want:          "<<<<<<< ours\nA1\n=======\nA2\n>>>>>>> theirs\nb\nc\nd\n<<<<<<< ours\nE1\n=======\nE2\n>>>>>>> theirs\n",
+			wantConflicts: 2,
+		},
+		{
+			name:   "missing final newline kept",
+			base:   "a\nb\nc",
+			ours:   "A\nb\nc",
+			theirs: "a\nb\nc",
+			want:   "A\nb\nc",
+		},
+		{
+			name:   "final newline added on one side",
+			base:   "a\nb\nc",
+			ours:   "a\nb\nc",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:          "conflict without final newline",
+			base:          "a\nb",
+			ours:          "a\nOURS",
+			theirs:        "a\nTHEIRS",
+			want:          "a\n<<<<<<< ours\nOURS\n=======\nTHEIRS\n>>>>>>> theirs\n",
+			wantConflicts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Merge(test.base, test.ours, test.theirs, "ours", "theirs")
+
+			if result.Text != test.want {
+				t.Errorf("Merge() text =\n%s\nwant\n%s", result.Text, test.want)
+			}
+			if result.Conflicts != test.wantConflicts {
+				t.Errorf("Merge() conflicts = %d, want %d", result.Conflicts, test.wantConflicts)
+			}
+		})
+	}
+}
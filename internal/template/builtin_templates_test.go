@@ -0,0 +1,74 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGoTemplateDefaultsWithDisplayName renders the defaults of the embedded
+// go template for a user whose git user.name is a display name, which must
+// not end up in the module path.
+func TestGoTemplateDefaultsWithDisplayName(t *testing.T) {
+	gitConfigPath := filepath.Join(t.TempDir(), "gitconfig")
+	err := os.WriteFile(gitConfigPath, []byte("[user]\n\tname = Jane Doe\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "templates", "go.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep the git config of the repository and the machine out of the test.
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfigPath)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	td, err := parseTemplate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user := gitConfigValue("user.name")(); user != "Jane Doe" {
+		t.Fatalf("user.name = %q, want %q", user, "Jane Doe")
+	}
+
+	inputs, err := ReadInputs(td, InputOptions{
+		NoInput:  true,
+		Builtins: map[string]any{"ProjectName": "demo"},
+	})
+	if err != nil {
+		t.Fatalf("ReadInputs() error = %v", err)
+	}
+
+	if got := inputs["ModuleName"]; got != "demo" {
+		t.Errorf("ModuleName = %q, want %q", got, "demo")
+	}
+}
+
+func TestGithubUser(t *testing.T) {
+	tests := []struct {
+		login string
+		want  string
+	}{
+		{"jane-doe", "jane-doe"},
+		{"JaneDoe42", "JaneDoe42"},
+		{"Jane Doe", ""},
+		{"-jane", ""},
+		{"jane/doe", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.login, func(t *testing.T) {
+			githubUserValue = func() string { return test.login }
+			t.Cleanup(func() { githubUserValue = gitConfigValue("github.user") })
+
+			if got := githubUser(); got != test.want {
+				t.Errorf("githubUser() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// resolve merges the templates td extends and includes into td. The bases
+// of every template come before it, the base template first, then the
+// included templates in order, and td itself comes last. Later templates
+// override inputs with the same name and files with the same path. A
+// template included more than once, e.g. by two included templates, is only
+// merged the first time. chain lists the templates being resolved to detect
+// cycles.
+func (td *TemplateData) resolve(chain []string) error {
+	templates, err := td.composition(chain, make(map[string]bool))
+	if err != nil {
+		return err
+	}
+
+	merged := &TemplateData{Files: make(map[string]TemplateFile)}
+	var parts []TemplatePart
+
+	for _, template := range templates {
+		if template != td {
+			td.Warnings = append(td.Warnings, template.Warnings...)
+		}
+		parts = append(parts, TemplatePart{Ref: template.Ref, Source: template.Source, Hooks: template.Hooks})
+
+		err = merged.merge(template)
+		if err != nil {
+			return err
+		}
+	}
+
+	td.Inputs = merged.Inputs
+	td.Files = merged.Files
+	td.Hooks = merged.Hooks
+	td.Parts = parts
+	if td.Description == "" {
+		td.Description = merged.Description
+	}
+
+	return nil
+}
+
+// composition loads the templates td is composed of and returns them in the
+// order they are merged, td last. Templates whose ref is in seen are already
+// part of the composition and left out.
+func (td *TemplateData) composition(chain []string, seen map[string]bool) ([]*TemplateData, error) {
+	var templates []*TemplateData
+
+	for _, baseName := range td.bases() {
+		// A cached registry version is composed of the base versions cached with it.
+		baseName, err := td.Source.baseRef(baseName)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", td.Ref, err)
+		}
+
+		if slices.Contains(chain, baseName) {
+			return nil, fmt.Errorf("template %s: inheritance cycle %s", td.Ref, strings.Join(append(chain, baseName), " -> "))
+		}
+
+		if seen[baseName] {
+			continue
+		}
+
+		base, err := loadTemplate(baseName)
+		if err != nil {
+			if errors.Is(err, ErrNotExists) {
+				return nil, fmt.Errorf("template %s: base template %s does not exist", td.Ref, baseName)
+			}
+			return nil, fmt.Errorf("template %s: %w", td.Ref, err)
+		}
+
+		baseTemplates, err := base.composition(append(slices.Clone(chain), baseName), seen)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, baseTemplates...)
+	}
+
+	seen[td.Ref] = true
+
+	return append(templates, td), nil
+}
+
+// bases returns the templates td extends and includes, in the order they are merged.
+func (td *TemplateData) bases() []string {
+	var bases []string
+	if td.Extends != "" {
+		bases = append(bases, td.Extends)
+	}
+	return append(bases, td.Include...)
+}
+
+// merge applies the inputs, files and hooks of other on top of td.
+func (td *TemplateData) merge(other *TemplateData) error {
+	if other.Description != "" {
+		td.Description = other.Description
+	}
+
+	for _, input := range other.Inputs {
+		if input.From == "" {
+			input.From = other.Ref
+		}
+
+		i := slices.IndexFunc(td.Inputs, func(existing TemplateInput) bool {
+			return existing.Name == input.Name
+		})
+		if i >= 0 {
+			td.Inputs[i] = input
+		} else {
+			td.Inputs = append(td.Inputs, input)
+		}
+	}
+
+	for filePath, file := range other.Files {
+		if file.From == "" {
+			file.From = other.Ref
+		}
+
+		existing, exists := td.Files[filePath]
+
+		// Base64 content cannot be joined, and a single file is either rendered or not.
+		if file.Append && (!file.isRendered() || exists && !existing.isRendered()) {
+			return fmt.Errorf("template %s: file %s: append only works on rendered text files, not on base64 encoded or verbatim ones", other.Ref, filePath)
+		}
+
+		if exists && file.Append {
+			file.Content = existing.Content + file.Content
+		}
+		file.Append = false
+
+		td.Files[filePath] = file
+	}
+
+	td.Hooks.PostCreate = append(td.Hooks.PostCreate, other.Hooks.PostCreate...)
+
+	return nil
+}
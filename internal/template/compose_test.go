@@ -0,0 +1,225 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	initTestTemplates(t, map[string]string{
+		"app": `version: v1
+name: app
+extends: base
+include: [license]
+inputs:
+  - name: Port
+    default: "9090"
+files:
+  README.md:
+    content: "app\n"
+    append: true
+  main.go: "package main\n"
+hooks:
+  post_create:
+    - run: echo app
+`,
+		"base": `version: v1
+name: base
+description: A base template
+inputs:
+  - name: Port
+    default: "8080"
+  - name: Module
+files:
+  README.md: "base\n"
+  main.go: "package base\n"
+hooks:
+  post_create:
+    - run: echo base
+`,
+		"license": `version: v1
+name: license
+files:
+  LICENSE: "MIT\n"
+`,
+	})
+
+	td, err := GetTemplate("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if td.Description != "A base template" {
+		t.Errorf("description = %q, want the description of base", td.Description)
+	}
+
+	var inputs []string
+	for _, input := range td.Inputs {
+		inputs = append(inputs, input.Name+"="+input.Default+" from "+input.From)
+	}
+	if want := []string{"Port=9090 from app", "Module= from base"}; !slices.Equal(inputs, want) {
+		t.Errorf("inputs = %v, want %v", inputs, want)
+	}
+
+	wantFiles := map[string]string{
+		"README.md": "base\napp\n",
+		"main.go":   "package main\n",
+		"LICENSE":   "MIT\n",
+	}
+	if len(td.Files) != len(wantFiles) {
+		t.Errorf("files = %v, want %d files", td.Files, len(wantFiles))
+	}
+	for filePath, want := range wantFiles {
+		if got := td.Files[filePath].Content; got != want {
+			t.Errorf("%s = %q, want %q", filePath, got, want)
+		}
+	}
+	if from := td.Files["LICENSE"].From; from != "license" {
+		t.Errorf("LICENSE from %q, want license", from)
+	}
+
+	var hooks []string
+	for _, hook := range td.Hooks.PostCreate {
+		hooks = append(hooks, hook.Run)
+	}
+	if want := []string{"echo base", "echo app"}; !slices.Equal(hooks, want) {
+		t.Errorf("post_create hooks = %v, want %v", hooks, want)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates map[string]string
+		wantErr   string
+	}{
+		{
+			name: "cycle",
+			templates: map[string]string{
+				"app":  "version: v1\nname: app\nextends: base\n",
+				"base": "version: v1\nname: base\ninclude: [app]\n",
+			},
+			wantErr: "inheritance cycle app -> base -> app",
+		},
+		{
+			name: "missing base",
+			templates: map[string]string{
+				"app": "version: v1\nname: app\nextends: base\n",
+			},
+			wantErr: "base template base does not exist",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initTestTemplates(t, test.templates)
+
+			_, err := GetTemplate("app")
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("GetTemplate() error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveDiamond(t *testing.T) {
+	initTestTemplates(t, map[string]string{
+		"app": `version: v1
+name: app
+include: [a, b]
+files:
+  notes.txt:
+    content: "app\n"
+    append: true
+`,
+		"a": `version: v1
+name: a
+include: [shared]
+files:
+  notes.txt:
+    content: "a\n"
+    append: true
+`,
+		"b": `version: v1
+name: b
+include: [shared]
+files:
+  notes.txt:
+    content: "b\n"
+    append: true
+`,
+		"shared": `version: v1
+name: shared
+files:
+  notes.txt: "shared\n"
+hooks:
+  post_create:
+    - run: echo shared
+`,
+	})
+
+	td, err := GetTemplate("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := td.Files["notes.txt"].Content, "shared\na\nb\napp\n"; got != want {
+		t.Errorf("notes.txt = %q, want %q", got, want)
+	}
+
+	if len(td.Hooks.PostCreate) != 1 {
+		t.Errorf("post_create hooks = %v, want the hook of shared once", td.Hooks.PostCreate)
+	}
+
+	var refs []string
+	for _, part := range td.Parts {
+		refs = append(refs, part.Ref)
+	}
+	if want := []string{"shared", "a", "b", "app"}; !slices.Equal(refs, want) {
+		t.Errorf("parts = %v, want %v", refs, want)
+	}
+}
+
+func TestResolveAppendNonText(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		file string
+	}{
+		{
+			name: "append base64",
+			base: `logo.png: "text\n"`,
+			file: `logo.png: {content: "aGk=", encoding: base64, append: true}`,
+		},
+		{
+			name: "append verbatim",
+			base: `notes.txt: "text\n"`,
+			file: `notes.txt: {content: "{{raw}}", verbatim: true, append: true}`,
+		},
+		{
+			name: "append to base64",
+			base: `logo.png: {content: "aGk=", encoding: base64}`,
+			file: `logo.png: {content: "text\n", append: true}`,
+		},
+		{
+			name: "append to verbatim",
+			base: `notes.txt: {content: "{{raw}}", verbatim: true}`,
+			file: `notes.txt: {content: "text\n", append: true}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initTestTemplates(t, map[string]string{
+				"base": "version: v1\nname: base\nfiles:\n  " + test.base + "\n",
+				"app":  "version: v1\nname: app\nextends: base\nfiles:\n  " + test.file + "\n",
+			})
+
+			_, err := GetTemplate("app")
+			if err == nil || !strings.Contains(err.Error(), "append only works on rendered text files") {
+				t.Errorf("GetTemplate() error = %v, want append to be refused", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package template
+
+import (
+	"crypto/rand"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+// TemplateFunc documents a helper function available in templates.
+type TemplateFunc struct {
+	Name        string
+	Usage       string
+	Description string
+	fn          any
+}
+
+var templateFuncs = []TemplateFunc{
+	{"lower", "lower STRING", "convert to lower case", strings.ToLower},
+	{"upper", "upper STRING", "convert to upper case", strings.ToUpper},
+	{"title", "title STRING", "upper case the first letter of every word", titleCase},
+	{"snakeCase", "snakeCase STRING", "convert to snake_case", snakeCase},
+	{"kebabCase", "kebabCase STRING", "convert to kebab-case", kebabCase},
+	{"camelCase", "camelCase STRING", "convert to camelCase", camelCase},
+	{"pascalCase", "pascalCase STRING", "convert to PascalCase", pascalCase},
+	{"replace", "replace OLD NEW STRING", "replace every OLD with NEW", replace},
+	{"join", "join SEPARATOR LIST", "join list elements with the separator", join},
+	{"indent", "indent SPACES STRING", "indent every line by a number of spaces", indent},
+	{"quote", "quote VALUE", "quote as a Go string literal", quote},
+	{"default", "default DEFAULT VALUE", "use DEFAULT when VALUE is empty", defaultValue},
+	{"env", "env NAME", "value of an environment variable", os.Getenv},
+	{"now", "now", "current time, e.g. {{now.Format \"2006-01-02\"}}", time.Now},
+	{"year", "year", "current year", func() int { return time.Now().Year() }},
+	{"uuid", "uuid", "random UUID (version 4)", newUUID},
+	{"gitUser", "gitUser", "user.name from git config", gitConfigValue("user.name")},
+	{"gitEmail", "gitEmail", "user.email from git config", gitConfigValue("user.email")},
+	{"githubUser", "githubUser", "github.user from git config, empty unless it is a valid GitHub login", githubUser},
+}
+
+// Funcs returns the helper functions available in templates.
+func Funcs() []TemplateFunc {
+	return templateFuncs
+}
+
+// FuncMap returns the helper functions for use with text/template.
+func FuncMap() template.FuncMap {
+	funcMap := make(template.FuncMap, len(templateFuncs))
+	for _, templateFunc := range templateFuncs {
+		funcMap[templateFunc.Name] = templateFunc.fn
+	}
+	return funcMap
+}
+
+// generator answers now, year and uuid from a record of an earlier render,
+// so rendering again reproduces it. UUIDs beyond the recorded ones are new
+// and added to the record.
+type generator struct {
+	generated *types.Generated
+	// used counts the recorded UUIDs returned so far, by name.
+	used map[string]int
+}
+
+// newGenerator returns a generator reading and extending generated, which
+// starts out as the current time and no UUIDs when nil or empty.
+func newGenerator(generated *types.Generated) *generator {
+	if generated == nil {
+		generated = &types.Generated{}
+	}
+	if generated.Time.IsZero() {
+		generated.Time = time.Now()
+	}
+
+	return &generator{generated: generated, used: make(map[string]int)}
+}
+
+// reset starts over at the first recorded UUID of every name.
+func (g *generator) reset() {
+	if g != nil {
+		clear(g.used)
+	}
+}
+
+// funcMap returns the helper functions with now, year and uuid answered for
+// the file or input called name. A nil generator returns new values.
+func (g *generator) funcMap(name string) template.FuncMap {
+	funcMap := FuncMap()
+	if g == nil {
+		return funcMap
+	}
+
+	funcMap["now"] = func() time.Time { return g.generated.Time }
+	funcMap["year"] = func() int { return g.generated.Time.Year() }
+	funcMap["uuid"] = func() (string, error) { return g.uuid(name) }
+
+	if git := g.generated.Git; git != nil {
+		funcMap["gitUser"] = func() string { return git["user.name"] }
+		funcMap["gitEmail"] = func() string { return git["user.email"] }
+		funcMap["githubUser"] = func() string { return githubLogin(git["github.user"]) }
+	}
+
+	return funcMap
+}
+
+func (g *generator) uuid(name string) (string, error) {
+	n := g.used[name]
+	g.used[name]++
+
+	if recorded := g.generated.UUIDs[name]; n < len(recorded) {
+		return recorded[n], nil
+	}
+
+	id := sequentialUUID(name, n)
+	if !g.generated.Sequential {
+		var err error
+		id, err = newUUID()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if g.generated.UUIDs == nil {
+		g.generated.UUIDs = make(map[string][]string)
+	}
+	g.generated.UUIDs[name] = append(g.generated.UUIDs[name], id)
+
+	return id, nil
+}
+
+// splitWords splits an identifier into words on separators and case changes,
+// e.g. "myHTTPServer-v2" becomes ["my", "HTTP", "Server", "v2"].
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+
+		if len(current) > 0 && unicode.IsUpper(r) {
+			previous := current[len(current)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				words = append(words, string(current))
+				current = nil
+			}
+		}
+
+		current = append(current, r)
+	}
+
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+
+	return words
+}
+
+// capitalize upper cases the first letter and lower cases the rest.
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+func snakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "_"))
+}
+
+func kebabCase(s string) string {
+	return strings.ToLower(strings.Join(splitWords(s), "-"))
+}
+
+func pascalCase(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func replace(old, replacement, s string) string {
+	return strings.ReplaceAll(s, old, replacement)
+}
+
+// join accepts any list, so it works with multiselect inputs as well as slices built in templates.
+func join(separator string, list any) (string, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected a list, got %T", list)
+	}
+
+	elements := make([]string, value.Len())
+	for i := range value.Len() {
+		elements[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+
+	return strings.Join(elements, separator), nil
+}
+
+func indent(spaces int, s string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.ReplaceAll(s, "\n", "\n"+padding)
+}
+
+func quote(value any) string {
+	return strconv.Quote(fmt.Sprint(value))
+}
+
+// defaultValue returns value unless it is empty, following the truthiness rules of `if`.
+func defaultValue(fallback, value any) any {
+	if truth, ok := template.IsTrue(value); !ok || !truth {
+		return fallback
+	}
+	return value
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// sequentialUUID returns the UUID numbered n for name: the first 8 hex digits
+// hash name, the last 12 count calls from 1.
+func sequentialUUID(name string, n int) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", hash.Sum32(), n+1)
+}
+
+// githubLoginPattern matches GitHub logins, which are safe in module paths
+// unlike user.name, usually a display name such as "Jane Doe".
+var githubLoginPattern = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+
+var githubUserValue = gitConfigValue("github.user")
+
+func githubUser() string {
+	return githubLogin(githubUserValue())
+}
+
+// githubLogin returns login if it is a valid GitHub login, empty otherwise.
+func githubLogin(login string) string {
+	if !githubLoginPattern.MatchString(login) {
+		return ""
+	}
+	return login
+}
+
+// gitConfigValue returns a function reading a key from the git config. The
+// value is looked up once and is empty when git or the key is not available.
+func gitConfigValue(key string) func() string {
+	return sync.OnceValue(func() string {
+		output, err := exec.Command("git", "config", "--get", key).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(output))
+	})
+}
@@ -0,0 +1,135 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// historyFileName is the file in the state directory remembering the last answers
+// given to the inputs of every template.
+const historyFileName = "template_history.yaml"
+
+// answerHistory is the content of the history file.
+type answerHistory struct {
+	// Templates maps template names to the last answer of each input.
+	Templates map[string]map[string]string `yaml:"templates"`
+}
+
+// History returns the answers last given to the inputs of a template.
+func History(name string) (map[string]string, error) {
+	history, err := readHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	return history.Templates[name], nil
+}
+
+// RememberInputs records the input values in data, which also holds the
+// builtins, as the last answers to the template's inputs. Secrets, computed
+// inputs and skipped questions are never recorded. Answers equal to the default are
+// forgotten, so defaults derived from other inputs keep following them.
+func RememberInputs(td *TemplateData, data map[string]any) error {
+	history, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	answers := make(map[string]string)
+	maps.Copy(answers, history.Templates[td.Ref])
+
+	values := FormatInputs(td, data)
+
+	for _, input := range td.Inputs {
+		value, exists := values[input.Name]
+		if !exists {
+			continue
+		}
+
+		// Skipped questions were not answered, their last answer is kept.
+		if ask, err := input.shouldAsk(data, FuncMap()); err != nil || !ask {
+			continue
+		}
+
+		input, err := input.withDefault(data, FuncMap())
+		if err != nil {
+			return err
+		}
+
+		defaultValue, err := input.resolve("")
+		if err == nil && defaultValue != nil && formatValue(defaultValue) == value {
+			delete(answers, input.Name)
+		} else {
+			answers[input.Name] = value
+		}
+	}
+
+	if maps.Equal(answers, history.Templates[td.Ref]) {
+		return nil
+	}
+
+	if history.Templates == nil {
+		history.Templates = make(map[string]map[string]string)
+	}
+	if len(answers) == 0 {
+		delete(history.Templates, td.Ref)
+	} else {
+		history.Templates[td.Ref] = answers
+	}
+
+	return writeHistory(history)
+}
+
+// ForgetHistory removes the answers remembered for a template and reports
+// whether there were any.
+func ForgetHistory(name string) (bool, error) {
+	history, err := readHistory()
+	if err != nil {
+		return false, err
+	}
+
+	if _, exists := history.Templates[name]; !exists {
+		return false, nil
+	}
+	delete(history.Templates, name)
+
+	return true, writeHistory(history)
+}
+
+func readHistory() (answerHistory, error) {
+	var history answerHistory
+
+	data, err := os.ReadFile(historyFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return history, nil
+		}
+		return history, err
+	}
+
+	err = yaml.Unmarshal(data, &history)
+	if err != nil {
+		return history, fmt.Errorf("parse %s: %w", historyFilePath(), err)
+	}
+
+	return history, nil
+}
+
+func writeHistory(history answerHistory) error {
+	data, err := yaml.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(historyFilePath(), data, 0o644)
+}
+
+func historyFilePath() string {
+	return filepath.Join(config.Get().StateDirPath, historyFileName)
+}
@@ -0,0 +1,218 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateHooks are commands a template runs at points of the project lifecycle.
+type TemplateHooks struct {
+	// PostCreate commands run in the new project directory after its files were written.
+	PostCreate []TemplateHook `yaml:"post_create,omitempty"`
+}
+
+// TemplateHook is a shell command run by a template.
+type TemplateHook struct {
+	// Run is the command, rendered as a template with the inputs before it runs.
+	Run string `yaml:"run"`
+	// When is a template condition, e.g. `.InitGit`, the hook only runs when it holds.
+	When string `yaml:"when,omitempty"`
+	// Dir is the working directory relative to the project directory.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// HookError reports which hook failed.
+type HookError struct {
+	Step    int
+	Total   int
+	Command string
+	Err     error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("post_create hook %d/%d `%s` failed: %v", e.Step, e.Total, e.Command, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// RunHooks runs the hooks one after another in projectDirPath, stopping at
+// the first failure. Command output is streamed to stdout, since stderr is
+// reserved for the shell integration.
+func RunHooks(hooks []TemplateHook, projectDirPath string, inputs map[string]any) error {
+	for i, hook := range hooks {
+		step := i + 1
+
+		if hook.When != "" {
+			run, err := evalCondition("when", hook.When, inputs, FuncMap())
+			if err != nil {
+				return &HookError{Step: step, Total: len(hooks), Command: hook.Run, Err: err}
+			}
+			if !run {
+				continue
+			}
+		}
+
+		command, err := renderString("run", hook.Run, inputs, FuncMap())
+		if err != nil {
+			return &HookError{Step: step, Total: len(hooks), Command: hook.Run, Err: err}
+		}
+
+		workDirPath := projectDirPath
+		if hook.Dir != "" && hook.Dir != "." {
+			dir, err := cleanFilePath(hook.Dir)
+			if err != nil {
+				return &HookError{Step: step, Total: len(hooks), Command: command, Err: err}
+			}
+			workDirPath = filepath.Join(projectDirPath, filepath.FromSlash(dir))
+		}
+
+		fmt.Printf("→ %s\n", command)
+
+		cmd := shellCommand(command)
+		cmd.Dir = workDirPath
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stdout
+
+		err = cmd.Run()
+		if err != nil {
+			return &HookError{Step: step, Total: len(hooks), Command: command, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// shellCommand returns a command running the command line with the system shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// trustedHooksFileName is the file in the state directory recording user templates whose hooks the user allowed.
+const trustedHooksFileName = "trusted_hooks.yaml"
+
+// trustedHooks is the content of the trusted hooks file.
+type trustedHooks struct {
+	Templates []trustedTemplate `yaml:"templates"`
+}
+
+type trustedTemplate struct {
+	Location string `yaml:"location"`
+	// HooksHash is the hash of the trusted hooks, any change to them requires trusting the template again.
+	HooksHash string `yaml:"hooks_sha256"`
+}
+
+// UntrustedHooks returns the parts of the template whose hooks require the
+// user's confirmation before running. Hooks of embedded templates are always
+// trusted, those of other templates once the user allowed their current
+// hooks. Every part is checked, so a user template shadowing a template that
+// is included cannot run commands unnoticed.
+func UntrustedHooks(td *TemplateData) ([]TemplatePart, error) {
+	trusted, err := readTrustedHooks()
+	if err != nil {
+		return nil, err
+	}
+
+	var untrusted []TemplatePart
+	for _, part := range hookParts(td) {
+		current := trustedTemplate{Location: hookLocation(part), HooksHash: hooksHash(part.Hooks)}
+		if !slices.Contains(trusted.Templates, current) {
+			untrusted = append(untrusted, part)
+		}
+	}
+
+	return untrusted, nil
+}
+
+// TrustHooks records that the user allowed the current hooks of the parts to run.
+func TrustHooks(parts []TemplatePart) error {
+	trusted, err := readTrustedHooks()
+	if err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		location := hookLocation(part)
+		trusted.Templates = slices.DeleteFunc(trusted.Templates, func(t trustedTemplate) bool {
+			return t.Location == location
+		})
+		trusted.Templates = append(trusted.Templates, trustedTemplate{Location: location, HooksHash: hooksHash(part.Hooks)})
+	}
+
+	data, err := yaml.Marshal(trusted)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(trustedHooksFilePath(), data, 0o644)
+}
+
+// hookParts returns the parts of the template that declare hooks and are not embedded.
+func hookParts(td *TemplateData) []TemplatePart {
+	parts := td.Parts
+	// Templates that were not resolved consist of themselves only.
+	if len(parts) == 0 {
+		parts = []TemplatePart{{Ref: td.Ref, Source: td.Source, Hooks: td.Hooks}}
+	}
+
+	var withHooks []TemplatePart
+	for _, part := range parts {
+		if part.Source.Kind != SourceEmbedded && len(part.Hooks.PostCreate) > 0 {
+			withHooks = append(withHooks, part)
+		}
+	}
+	return withHooks
+}
+
+func readTrustedHooks() (trustedHooks, error) {
+	var trusted trustedHooks
+
+	data, err := os.ReadFile(trustedHooksFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return trusted, nil
+		}
+		return trusted, err
+	}
+
+	err = yaml.Unmarshal(data, &trusted)
+	if err != nil {
+		return trusted, fmt.Errorf("parse %s: %w", trustedHooksFilePath(), err)
+	}
+
+	return trusted, nil
+}
+
+func trustedHooksFilePath() string {
+	return filepath.Join(config.Get().StateDirPath, trustedHooksFileName)
+}
+
+// hookLocation identifies where a template was loaded from.
+func hookLocation(part TemplatePart) string {
+	// Templates loaded from a file are referred to by their path.
+	if part.Source.Path == part.Ref {
+		return part.Source.Path
+	}
+	return filepath.Join(part.Source.Path, part.Ref)
+}
+
+func hooksHash(hooks TemplateHooks) string {
+	data, _ := yaml.Marshal(hooks)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
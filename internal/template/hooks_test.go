@@ -0,0 +1,113 @@
+package template
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestHookTrust(t *testing.T) {
+	initTestTemplates(t, map[string]string{
+		"base": "version: v1\nname: base\nfiles:\n  README.md: \"# base\\n\"\nhooks:\n  post_create:\n    - run: echo base\n",
+		"app":  "version: v1\nname: app\nextends: base\nhooks:\n  post_create:\n    - run: echo app\n",
+	})
+
+	td, err := GetTemplate("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hooks of every template the template is composed of need trust.
+	parts := assertUntrustedHooks(t, td, "base", "app")
+
+	err = TrustHooks(parts[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUntrustedHooks(t, td, "base")
+
+	err = TrustHooks(parts[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUntrustedHooks(t, td)
+
+	// Changed hooks must be trusted again.
+	td.Parts[0].Hooks.PostCreate[0].Run = "echo changed"
+	assertUntrustedHooks(t, td, "base")
+
+	// Embedded templates are always trusted.
+	td.Parts[0].Source.Kind = SourceEmbedded
+	assertUntrustedHooks(t, td)
+}
+
+// assertUntrustedHooks checks the refs of the untrusted parts of the template and returns the parts.
+func assertUntrustedHooks(t *testing.T, td *TemplateData, want ...string) []TemplatePart {
+	t.Helper()
+
+	parts, err := UntrustedHooks(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, part := range parts {
+		got = append(got, part.Ref)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("UntrustedHooks() = %v, want %v", got, want)
+	}
+
+	return parts
+}
+
+func TestRunHooks(t *testing.T) {
+	projectPath := t.TempDir()
+	err := os.MkdirAll(filepath.Join(projectPath, "web"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hooks := []TemplateHook{
+		{Run: "echo {{.ProjectName}} > name.txt"},
+		{Run: "echo skipped > skipped.txt", When: ".InitGit"},
+		{Run: "echo web > dir.txt", Dir: "web"},
+		{Run: "exit 3"},
+		{Run: "echo after > after.txt"},
+	}
+
+	err = RunHooks(hooks, projectPath, map[string]any{"ProjectName": "api", "InitGit": false})
+
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("RunHooks() error = %v, want a HookError", err)
+	}
+	if hookErr.Step != 4 || hookErr.Total != len(hooks) {
+		t.Errorf("failed hook = %d/%d, want 4/%d", hookErr.Step, hookErr.Total, len(hooks))
+	}
+
+	wantFiles := map[string]string{
+		"name.txt":    "api\n",
+		"web/dir.txt": "web\n",
+		"skipped.txt": "",
+		"after.txt":   "",
+	}
+	for name, want := range wantFiles {
+		content, err := os.ReadFile(filepath.Join(projectPath, filepath.FromSlash(name)))
+		if want == "" {
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s exists, want the hook not to run", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(content) != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+	}
+}
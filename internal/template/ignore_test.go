@@ -0,0 +1,49 @@
+package template
+
+import "testing"
+
+func TestIgnoreRules(t *testing.T) {
+	rules := parseIgnoreRules([]byte(`# build output
+bin/
+*.log
+!keep.log
+/root.txt
+docs/**/*.tmp
+data/**
+\#notes
+file?.go
+[ab].txt
+`))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "bin", isDir: true, want: true},
+		{path: "cmd/bin", isDir: true, want: true},
+		{path: "bin", isDir: false, want: false},
+		{path: "debug.log", want: true},
+		{path: "logs/debug.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "root.txt", want: true},
+		{path: "sub/root.txt", want: false},
+		{path: "docs/a.tmp", want: true},
+		{path: "docs/a/b/c.tmp", want: true},
+		{path: "a.tmp", want: false},
+		{path: "data/x/y", want: true},
+		{path: "data", isDir: true, want: false},
+		{path: "#notes", want: true},
+		{path: "file1.go", want: true},
+		{path: "file10.go", want: false},
+		{path: "a.txt", want: true},
+		{path: "c.txt", want: false},
+		{path: "main.go", want: false},
+	}
+
+	for _, test := range tests {
+		if got := rules.match(test.path, test.isDir); got != test.want {
+			t.Errorf("match(%q, %v) = %v, want %v", test.path, test.isDir, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,697 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gurleensethi/cradle/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Input types supported by templates.
+const (
+	InputTypeString      = "string"
+	InputTypeInt         = "int"
+	InputTypeFloat       = "float"
+	InputTypeBool        = "bool"
+	InputTypeSelect      = "select"
+	InputTypeMultiSelect = "multiselect"
+	// InputTypeSecret is a string that is masked while typing and never stored by cradle.
+	InputTypeSecret = "secret"
+)
+
+// InputTypes lists all supported input types.
+var InputTypes = []string{
+	InputTypeString,
+	InputTypeInt,
+	InputTypeFloat,
+	InputTypeBool,
+	InputTypeSelect,
+	InputTypeMultiSelect,
+	InputTypeSecret,
+}
+
+// TemplateInputValidation defines validation rules for template inputs.
+type TemplateInputValidation struct {
+	Pattern string   `yaml:"pattern,omitempty"`
+	Message string   `yaml:"message,omitempty"`
+	MinLen  *int     `yaml:"min_len,omitempty"`
+	MaxLen  *int     `yaml:"max_len,omitempty"`
+	Min     *float64 `yaml:"min,omitempty"`
+	Max     *float64 `yaml:"max,omitempty"`
+}
+
+func (tiv *TemplateInputValidation) Validate(inputType, input string) string {
+	if inputType == InputTypeFloat {
+		numValue, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return "must be a valid number (float)"
+		}
+
+		if tiv.Min != nil && float64(numValue) < *tiv.Min {
+			return fmt.Sprintf("number must be greater than %v", *tiv.Min)
+		}
+
+		if tiv.Max != nil && float64(numValue) > *tiv.Max {
+			return fmt.Sprintf("number must be less than %v", *tiv.Max)
+		}
+	}
+
+	if inputType == InputTypeInt {
+		numValue, err := strconv.Atoi(input)
+		if err != nil {
+			return "must be a valid number (integer)"
+		}
+
+		if tiv.Min != nil && numValue < int(*tiv.Min) {
+			return fmt.Sprintf("number must be greater than %v", *tiv.Min)
+		}
+
+		if tiv.Max != nil && numValue > int(*tiv.Max) {
+			return fmt.Sprintf("number must be less than %v", *tiv.Max)
+		}
+	}
+
+	if inputType == InputTypeString || inputType == InputTypeSecret {
+		if tiv.Pattern != "" {
+			regex, err := regexp.Compile(tiv.Pattern)
+			if err != nil {
+				return fmt.Sprintf("invalid regex: %v %v", err, tiv.Pattern)
+			}
+
+			if !regex.MatchString(input) {
+				return fmt.Sprintf("should match pattern `%s`", tiv.Pattern)
+			}
+		}
+
+		if tiv.MinLen != nil {
+			if len(input) < *tiv.MinLen {
+				return fmt.Sprintf("need to be at least %d characters", *tiv.MinLen)
+			}
+		}
+
+		if tiv.MaxLen != nil {
+			if len(input) > *tiv.MaxLen {
+				return fmt.Sprintf("cannot be longer than %d characters", *tiv.MaxLen)
+			}
+		}
+	}
+
+	return ""
+}
+
+// TemplateInput defines a user input field for a template.
+type TemplateInput struct {
+	Name string `yaml:"name"`
+	// Default is used when no value is given. It may be a template referring
+	// to earlier inputs, e.g. `github.com/{{gitUser}}/{{.ProjectName}}`.
+	Default     string `yaml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Deprecated: Pattern has no effect, validate.pattern restricts the input.
+	Pattern string `yaml:"pattern,omitempty"`
+	Type    string `yaml:"type,omitempty"`
+	// Options are the choices of select and multiselect inputs.
+	Options  []string                `yaml:"options,omitempty"`
+	Validate TemplateInputValidation `yaml:"validate,omitempty"`
+	// AskIf is a condition on earlier inputs, such as `eq .Kind "http"`. The
+	// input is only asked when it holds and takes its default otherwise.
+	AskIf string `yaml:"ask_if,omitempty"`
+	// Computed is a template deriving the value from earlier inputs. Computed
+	// inputs are never asked and cannot be set.
+	Computed string `yaml:"computed,omitempty"`
+
+	// From is the template that declared the input, set when templates are composed.
+	From string `yaml:"-"`
+}
+
+// Kind returns the input type, defaulting to string when none is declared.
+func (input TemplateInput) Kind() string {
+	if input.Type == "" {
+		return InputTypeString
+	}
+	return input.Type
+}
+
+// IsSecret reports whether the input holds a secret that must never be stored.
+func (input TemplateInput) IsSecret() bool {
+	return input.Kind() == InputTypeSecret
+}
+
+// IsComputed reports whether the input is derived from other inputs instead of asked.
+func (input TemplateInput) IsComputed() bool {
+	return input.Computed != ""
+}
+
+// InputOptions controls how template input values are collected.
+type InputOptions struct {
+	// Values are input values provided upfront, e.g. from the command line.
+	// Inputs with a provided value are not asked for. Multiselect values are
+	// separated by commas.
+	Values map[string]string
+	// NoInput disables prompting. Inputs without a provided value use their
+	// default, and missing required inputs are reported as an error.
+	NoInput bool
+	// Builtins are values available to templated defaults, conditions and
+	// computed inputs next to the inputs themselves, such as ProjectName.
+	Builtins map[string]any
+	// History holds the answers given last time, offered as the defaults of
+	// the inputs that are asked. Secrets and invalid answers are ignored.
+	History map[string]string
+	// Generated pins the values of now, year and uuid in defaults and
+	// computed inputs to those of an earlier render and records new ones,
+	// see Render. It may be nil.
+	Generated *types.Generated
+}
+
+// ReadUserInputs collects validated input values from the user.
+func ReadUserInputs(td *TemplateData) (map[string]any, error) {
+	return ReadInputs(td, InputOptions{})
+}
+
+// ReadInputs collects validated input values, asking the user for every
+// input that was not provided in opts unless prompting is disabled. Values are
+// typed according to the input type: int, float64, bool, []string for
+// multiselect inputs and string otherwise.
+//
+// Inputs are evaluated in order, so defaults, ask_if conditions and computed
+// inputs can refer to the inputs declared before them.
+func ReadInputs(td *TemplateData, opts InputOptions) (map[string]any, error) {
+	inputsByName := make(map[string]TemplateInput)
+	for _, input := range td.Inputs {
+		inputsByName[input.Name] = input
+	}
+
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(opts.Values)) {
+		input, exists := inputsByName[name]
+		switch {
+		case !exists:
+			problems = append(problems, fmt.Sprintf("unknown input %s", name))
+		case input.IsComputed():
+			problems = append(problems, fmt.Sprintf("input %s is computed and cannot be set", name))
+		case opts.Values[name] != "":
+			// Empty values fall back to the default, which is only known once earlier inputs are.
+			if _, err := input.resolve(opts.Values[name]); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	reader := &inputReader{
+		inputs:     slices.Clone(td.Inputs),
+		opts:       opts,
+		collectors: make(map[string]func(TemplateInput) (any, error)),
+		generator:  newGenerator(opts.Generated),
+	}
+
+	var groups []*huh.Group
+
+	for i, input := range reader.inputs {
+		if _, provided := opts.Values[input.Name]; provided || opts.NoInput || input.IsComputed() {
+			continue
+		}
+
+		if answer, exists := opts.History[input.Name]; exists && !input.IsSecret() {
+			if _, err := input.resolve(answer); err == nil {
+				input.Default = answer
+				reader.inputs[i] = input
+			}
+		}
+
+		field, collect, setDefault := input.formField()
+		reader.collectors[input.Name] = collect
+
+		// Every question is a group of its own, so it is only shown, and its
+		// default only rendered, once the answers it depends on are given.
+		group := huh.NewGroup(field).WithHideFunc(func() bool {
+			data, err := reader.evaluate(i)
+			if err != nil {
+				// Ask anyway, the problem is reported once the form is submitted.
+				return false
+			}
+
+			funcMap := reader.funcMap(input)
+
+			current, err := input.withDefault(data, funcMap)
+			if err != nil {
+				return false
+			}
+			setDefault(current.Default)
+
+			ask, err := input.shouldAsk(data, funcMap)
+			return err == nil && !ask
+		})
+
+		groups = append(groups, group)
+	}
+
+	if len(groups) > 0 {
+		fmt.Println(
+			lipgloss.NewStyle().
+				Margin(1).
+				Underline(true).
+				Render(td.Name + " - " + td.Description),
+		)
+
+		form := huh.NewForm(groups...).WithProgramOptions(tea.WithOutput(os.Stdout))
+
+		err := form.Run()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := reader.evaluate(len(reader.inputs))
+	if err != nil {
+		return nil, err
+	}
+
+	userInputs := make(map[string]any, len(td.Inputs))
+	for _, input := range td.Inputs {
+		userInputs[input.Name] = data[input.Name]
+	}
+
+	return userInputs, nil
+}
+
+// inputReader evaluates the inputs of a template in order.
+type inputReader struct {
+	// inputs are the template inputs, with remembered answers as defaults.
+	inputs []TemplateInput
+	opts   InputOptions
+	// collectors return the answers of inputs asked in the form, given the
+	// input with its default rendered.
+	collectors map[string]func(TemplateInput) (any, error)
+	generator  *generator
+}
+
+// funcMap returns the helper functions for the templates of input.
+func (r *inputReader) funcMap(input TemplateInput) template.FuncMap {
+	return r.generator.funcMap("input:" + input.Name)
+}
+
+// evaluate resolves the first n inputs and returns the data later templates
+// are rendered with: the builtins and the typed input values. Inputs whose
+// ask_if condition is false take their default.
+func (r *inputReader) evaluate(n int) (map[string]any, error) {
+	data := make(map[string]any)
+	maps.Copy(data, r.opts.Builtins)
+
+	// Every evaluation starts from the first recorded UUIDs, so evaluating
+	// again returns the same values.
+	r.generator.reset()
+
+	var problems []string
+	var missing []string
+
+	for _, input := range r.inputs[:n] {
+		value, err := r.value(input, data)
+		if errors.Is(err, errRequired) {
+			missing = append(missing, input.Name)
+		} else if err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		data[input.Name] = value
+	}
+
+	if len(missing) > 0 {
+		problems = append(problems, "missing required inputs: "+strings.Join(missing, ", "))
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	return data, nil
+}
+
+// value resolves a single input against the data of the inputs before it.
+func (r *inputReader) value(input TemplateInput, data map[string]any) (any, error) {
+	funcMap := r.funcMap(input)
+
+	if input.IsComputed() {
+		rendered, err := renderString(input.Name, input.Computed, data, funcMap)
+		if err != nil {
+			return nil, fmt.Errorf("input %s: computed: %w", input.Name, err)
+		}
+		return input.resolve(strings.TrimSpace(rendered))
+	}
+
+	input, err := input.withDefault(data, funcMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if value, provided := r.opts.Values[input.Name]; provided {
+		return input.resolve(value)
+	}
+
+	ask, err := input.shouldAsk(data, funcMap)
+	if err != nil {
+		return nil, err
+	}
+	if !ask {
+		// Skipped questions are not required, they take the default if any.
+		input.Required = false
+		return input.resolve("")
+	}
+
+	if collect, asked := r.collectors[input.Name]; asked {
+		return collect(input)
+	}
+
+	return input.resolve("")
+}
+
+// withDefault returns the input with its default rendered against data.
+func (input TemplateInput) withDefault(data map[string]any, funcMap template.FuncMap) (TemplateInput, error) {
+	if !strings.Contains(input.Default, "{{") {
+		return input, nil
+	}
+
+	rendered, err := renderString(input.Name, input.Default, data, funcMap)
+	if err != nil {
+		return input, fmt.Errorf("input %s: default: %w", input.Name, err)
+	}
+
+	input.Default = strings.TrimSpace(rendered)
+	return input, nil
+}
+
+// shouldAsk evaluates the ask_if condition of the input against data.
+func (input TemplateInput) shouldAsk(data map[string]any, funcMap template.FuncMap) (bool, error) {
+	if input.AskIf == "" {
+		return true, nil
+	}
+
+	ask, err := evalCondition(input.Name, input.AskIf, data, funcMap)
+	if err != nil {
+		return false, fmt.Errorf("input %s: ask_if: %w", input.Name, err)
+	}
+	return ask, nil
+}
+
+// FormatInputs converts typed input values back to their text form, as
+// accepted in InputOptions.Values. Secrets, computed inputs and undeclared
+// values, such as builtin inputs, are left out.
+func FormatInputs(td *TemplateData, inputs map[string]any) map[string]string {
+	values := make(map[string]string)
+
+	for _, input := range td.Inputs {
+		value, exists := inputs[input.Name]
+		if !exists || value == nil || input.IsSecret() || input.IsComputed() {
+			continue
+		}
+
+		values[input.Name] = formatValue(value)
+	}
+
+	return values
+}
+
+// formatValue returns the text form of a typed input value.
+func formatValue(value any) string {
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// formField returns the form field asking for the input, a function that
+// returns the typed answer once the form was submitted, and a function that
+// updates the default shown once it was rendered from earlier answers.
+func (input TemplateInput) formField() (huh.Field, func(TemplateInput) (any, error), func(string)) {
+	title := input.Name
+	if input.Required {
+		title += "*"
+	}
+
+	describe := func(defaultValue string) string {
+		description := input.Description
+		if defaultValue != "" && input.IsSecret() {
+			description += "\n(default: hidden)"
+		} else if defaultValue != "" {
+			description += "\n(default: " + defaultValue + ")"
+		}
+		return description
+	}
+
+	switch input.Kind() {
+	case InputTypeBool:
+		answer, _ := strconv.ParseBool(input.Default)
+		primed := answer
+
+		field := huh.NewConfirm().
+			Title(title).
+			Description(describe(input.Default)).
+			Value(&answer).
+			Key(input.Name)
+
+		setDefault := func(defaultValue string) {
+			field.Description(describe(defaultValue))
+
+			// Keep answers the user changed.
+			if answer == primed {
+				answer, _ = strconv.ParseBool(defaultValue)
+				primed = answer
+			}
+		}
+
+		return field, func(TemplateInput) (any, error) { return answer, nil }, setDefault
+
+	case InputTypeSelect:
+		answer := input.Default
+
+		field := huh.NewSelect[string]().
+			Title(title).
+			Description(describe(input.Default)).
+			Options(huh.NewOptions(input.Options...)...).
+			Value(&answer).
+			Key(input.Name)
+
+		// The field selects the first option when the default is not one.
+		primed := answer
+
+		setDefault := func(defaultValue string) {
+			field.Description(describe(defaultValue))
+
+			if answer == primed {
+				answer = defaultValue
+				// Moves the cursor to the new default.
+				field.Value(&answer)
+				primed = answer
+			}
+		}
+
+		return field, func(current TemplateInput) (any, error) { return current.resolve(answer) }, setDefault
+
+	case InputTypeMultiSelect:
+		answer := splitList(input.Default)
+		primed := answer
+
+		field := huh.NewMultiSelect[string]().
+			Title(title).
+			Description(describe(input.Default)).
+			Options(huh.NewOptions(input.Options...)...).
+			Value(&answer).
+			Validate(func(selected []string) error {
+				if input.Required && len(selected) == 0 {
+					return fmt.Errorf("%s %w", input.Name, errRequired)
+				}
+				return nil
+			}).
+			Key(input.Name)
+
+		setDefault := func(defaultValue string) {
+			field.Description(describe(defaultValue))
+
+			if slices.Equal(answer, primed) {
+				answer = splitList(defaultValue)
+				primed = answer
+				// Resetting the options selects the new default.
+				field.Options(huh.NewOptions(input.Options...)...)
+			}
+		}
+
+		return field, func(TemplateInput) (any, error) {
+			if answer == nil {
+				answer = []string{}
+			}
+			return answer, nil
+		}, setDefault
+
+	default:
+		var answer string
+		// current holds the rendered default the answer falls back to.
+		current := input
+
+		field := huh.NewInput().
+			Title(title).
+			Description(describe(input.Default)).
+			Validate(func(s string) error {
+				_, err := current.resolve(s)
+				return err
+			}).
+			Value(&answer).
+			Key(input.Name)
+
+		setDefault := func(defaultValue string) {
+			current.Default = defaultValue
+			field.Description(describe(defaultValue))
+			if !input.IsSecret() {
+				field.Placeholder(defaultValue)
+			}
+		}
+		setDefault(input.Default)
+
+		if input.IsSecret() {
+			field = field.EchoMode(huh.EchoModePassword)
+		}
+
+		return field, func(current TemplateInput) (any, error) { return current.resolve(answer) }, setDefault
+	}
+}
+
+// errRequired is returned when a required input has neither a value nor a default.
+var errRequired = errors.New("is required")
+
+// resolve validates a raw input value and converts it to the input type,
+// falling back to the default when the value is empty. Empty optional numbers
+// resolve to nil.
+func (input TemplateInput) resolve(value string) (any, error) {
+	if value == "" {
+		value = input.Default
+	}
+
+	if value == "" && input.Required {
+		return nil, fmt.Errorf("%s %w", input.Name, errRequired)
+	}
+
+	switch input.Kind() {
+	case InputTypeBool:
+		if value == "" {
+			return false, nil
+		}
+
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", input.Name)
+		}
+		return b, nil
+
+	case InputTypeSelect:
+		if value != "" && !slices.Contains(input.Options, value) {
+			return nil, fmt.Errorf("%s must be one of: %s", input.Name, strings.Join(input.Options, ", "))
+		}
+		return value, nil
+
+	case InputTypeMultiSelect:
+		selected := splitList(value)
+		for _, option := range selected {
+			if !slices.Contains(input.Options, option) {
+				return nil, fmt.Errorf("%s: %s is not one of: %s", input.Name, option, strings.Join(input.Options, ", "))
+			}
+		}
+		return selected, nil
+	}
+
+	if value == "" {
+		if input.Kind() == InputTypeInt || input.Kind() == InputTypeFloat {
+			return nil, nil
+		}
+		return "", nil
+	}
+
+	validationMessage := input.Validate.Validate(input.Kind(), value)
+	if validationMessage != "" {
+		return nil, errors.New(input.Name + " " + validationMessage)
+	}
+
+	switch input.Kind() {
+	case InputTypeInt:
+		return strconv.Atoi(value)
+	case InputTypeFloat:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(value string) []string {
+	list := []string{}
+	for _, element := range strings.Split(value, ",") {
+		element = strings.TrimSpace(element)
+		if element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
+
+// ReadValuesFile reads input values from a YAML or JSON file containing a
+// mapping of input names to values. Lists, e.g. for multiselect inputs, are
+// joined with commas.
+func ReadValuesFile(filePath string) (map[string]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawValues map[string]yaml.Node
+	err = yaml.Unmarshal(data, &rawValues)
+	if err != nil {
+		return nil, fmt.Errorf("parse values file %s: %w", filePath, err)
+	}
+
+	values := make(map[string]string)
+	for name, rawValue := range rawValues {
+		value, err := scalarText(&rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("values file %s: %s %w", filePath, name, err)
+		}
+
+		values[name] = value
+	}
+
+	return values, nil
+}
+
+// scalarText returns the literal text of a scalar node, so values such as
+// `1.20` are kept as written. Sequences of scalars are joined with commas.
+func scalarText(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		var elements []string
+		for _, element := range node.Content {
+			if element.Kind != yaml.ScalarNode {
+				return "", errors.New("must be a list of scalar values")
+			}
+			elements = append(elements, element.Value)
+		}
+		return strings.Join(elements, ","), nil
+	default:
+		return "", errors.New("must be a scalar value or a list")
+	}
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadInputsInOrder(t *testing.T) {
+	td := &TemplateData{
+		Name: "service",
+		Inputs: []TemplateInput{
+			{Name: "Module", Default: "github.com/acme/{{.ProjectName}}"},
+			{Name: "Kind", Type: InputTypeSelect, Options: []string{"cli", "http"}, Default: "cli"},
+			{Name: "Port", Type: InputTypeInt, Default: "8080", AskIf: `eq .Kind "http"`},
+			{Name: "Token", Required: true, AskIf: `eq .Kind "http"`},
+			{Name: "Image", Computed: "{{.Module}}:{{.Kind}}"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		// want are the input values, wantErr is part of the error otherwise.
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: map[string]any{
+				"Module": "github.com/acme/api",
+				"Kind":   "cli",
+				"Port":   8080,
+				"Token":  "",
+				"Image":  "github.com/acme/api:cli",
+			},
+		},
+		{
+			name:   "condition holds",
+			values: map[string]string{"Module": "example.com/x", "Kind": "http", "Port": "9090", "Token": "secret"},
+			want: map[string]any{
+				"Module": "example.com/x",
+				"Kind":   "http",
+				"Port":   9090,
+				"Token":  "secret",
+				"Image":  "example.com/x:http",
+			},
+		},
+		{
+			name:    "required when the condition holds",
+			values:  map[string]string{"Kind": "http"},
+			wantErr: "missing required inputs: Token",
+		},
+		{
+			name:    "computed input set",
+			values:  map[string]string{"Image": "custom"},
+			wantErr: "input Image is computed and cannot be set",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadInputs(td, InputOptions{
+				Values:   test.values,
+				NoInput:  true,
+				Builtins: map[string]any{"ProjectName": "api"},
+			})
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("ReadInputs() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ReadInputs() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
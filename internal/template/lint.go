@@ -0,0 +1,214 @@
+package template
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// builtinInputs are values available to every template without being declared as inputs.
+var builtinInputs = []string{"ProjectName"}
+
+// Lint checks a template for problems that would only surface when a project
+// is created from it. It returns one message per problem found.
+func Lint(td *TemplateData) []string {
+	var issues []string
+
+	if err := td.Validate(); err != nil {
+		issues = append(issues, err.Error())
+	}
+
+	declared := make(map[string]struct{})
+	for _, name := range builtinInputs {
+		declared[name] = struct{}{}
+	}
+
+	for i, input := range td.Inputs {
+		if input.Name == "" {
+			issues = append(issues, fmt.Sprintf("input #%d: name cannot be empty", i+1))
+			continue
+		}
+
+		if _, exists := declared[input.Name]; exists {
+			issues = append(issues, fmt.Sprintf("input %s: declared more than once", input.Name))
+		}
+
+		// Defaults, conditions and computed values only see the inputs declared before.
+		var parts []lintPart
+		if strings.Contains(input.Default, "{{") {
+			parts = append(parts, lintPart{"default", input.Default})
+		}
+		if input.AskIf != "" {
+			parts = append(parts, lintPart{"ask_if", conditionTemplate(input.AskIf)})
+		}
+		if input.IsComputed() {
+			parts = append(parts, lintPart{"computed", input.Computed})
+
+			if input.Default != "" || input.AskIf != "" || input.Required {
+				issues = append(issues, fmt.Sprintf("input %s: computed inputs are never asked, default, ask_if and required have no effect", input.Name))
+			}
+		}
+		issues = append(issues, lintParts("input "+input.Name, parts, declared)...)
+
+		declared[input.Name] = struct{}{}
+
+		if input.Validate.Pattern != "" {
+			if _, err := regexp.Compile(input.Validate.Pattern); err != nil {
+				issues = append(issues, fmt.Sprintf("input %s: invalid validate.pattern: %v", input.Name, err))
+				continue
+			}
+		}
+
+		isChoice := input.Kind() == InputTypeSelect || input.Kind() == InputTypeMultiSelect
+		if isChoice && len(input.Options) == 0 {
+			issues = append(issues, fmt.Sprintf("input %s: %s inputs need options", input.Name, input.Kind()))
+		} else if !isChoice && len(input.Options) > 0 {
+			issues = append(issues, fmt.Sprintf("input %s: options are only used by select and multiselect inputs", input.Name))
+		}
+
+		// Templated defaults are only known once the inputs before are.
+		if input.Default != "" && !strings.Contains(input.Default, "{{") {
+			if _, err := input.resolve(input.Default); err != nil {
+				issues = append(issues, fmt.Sprintf("input %s: invalid default: %v", input.Name, err))
+			}
+		}
+	}
+
+	for _, filePath := range slices.Sorted(maps.Keys(td.Files)) {
+		templateFile := td.Files[filePath]
+
+		// Paths, conditions and contents are all templates.
+		parts := []lintPart{{"path", filePath}}
+		if templateFile.When != "" {
+			parts = append(parts, lintPart{"when", conditionTemplate(templateFile.When)})
+		}
+		switch templateFile.Encoding {
+		case "":
+			if !templateFile.Verbatim {
+				parts = append(parts, lintPart{"content", templateFile.Content})
+			}
+		case EncodingBase64:
+			if _, err := base64.StdEncoding.DecodeString(templateFile.Content); err != nil {
+				issues = append(issues, fmt.Sprintf("file %s: invalid base64 content: %v", filePath, err))
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("file %s: unknown encoding %q, expected %s", filePath, templateFile.Encoding, EncodingBase64))
+		}
+
+		issues = append(issues, lintParts("file "+filePath, parts, declared)...)
+	}
+
+	for i, hook := range td.Hooks.PostCreate {
+		label := fmt.Sprintf("post_create hook #%d", i+1)
+
+		if strings.TrimSpace(hook.Run) == "" {
+			issues = append(issues, label+": run cannot be empty")
+		}
+
+		if hook.Dir != "" && hook.Dir != "." {
+			if _, err := cleanFilePath(hook.Dir); err != nil {
+				issues = append(issues, fmt.Sprintf("%s: dir: %v", label, err))
+			}
+		}
+
+		parts := []lintPart{{"run", hook.Run}}
+		if hook.When != "" {
+			parts = append(parts, lintPart{"when", conditionTemplate(hook.When)})
+		}
+
+		issues = append(issues, lintParts(label, parts, declared)...)
+	}
+
+	return issues
+}
+
+// lintPart is a piece of a template that is parsed as a text template.
+type lintPart struct {
+	name string
+	text string
+}
+
+// lintParts parses every part and reports parse errors and references to undeclared inputs.
+func lintParts(label string, parts []lintPart, declared map[string]struct{}) []string {
+	var issues []string
+	referenced := make(map[string]struct{})
+
+	for _, part := range parts {
+		t, err := template.New(label).Funcs(FuncMap()).Parse(part.text)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %s: %v", label, part.name, err))
+			continue
+		}
+
+		for _, tree := range t.Templates() {
+			if tree.Tree != nil {
+				collectFields(tree.Tree.Root, true, referenced)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(referenced)) {
+		if _, exists := declared[name]; !exists {
+			issues = append(issues, fmt.Sprintf("%s: references undeclared input .%s", label, name))
+		}
+	}
+
+	return issues
+}
+
+// collectFields records the top-level fields referenced on the root data
+// value. Fields inside range and with blocks are skipped, since dot no longer
+// refers to the template inputs there.
+func collectFields(node parse.Node, dotIsRoot bool, fields map[string]struct{}) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, child := range node.Nodes {
+			collectFields(child, dotIsRoot, fields)
+		}
+	case *parse.ActionNode:
+		collectFields(node.Pipe, dotIsRoot, fields)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			collectFields(cmd, dotIsRoot, fields)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			collectFields(arg, dotIsRoot, fields)
+		}
+	case *parse.ChainNode:
+		collectFields(node.Node, dotIsRoot, fields)
+	case *parse.FieldNode:
+		if dotIsRoot {
+			fields[node.Ident[0]] = struct{}{}
+		}
+	case *parse.VariableNode:
+		if node.Ident[0] == "$" && len(node.Ident) > 1 {
+			fields[node.Ident[1]] = struct{}{}
+		}
+	case *parse.IfNode:
+		collectFields(node.Pipe, dotIsRoot, fields)
+		collectFields(node.List, dotIsRoot, fields)
+		collectFields(node.ElseList, dotIsRoot, fields)
+	case *parse.RangeNode:
+		collectFields(node.Pipe, dotIsRoot, fields)
+		collectFields(node.List, false, fields)
+		collectFields(node.ElseList, dotIsRoot, fields)
+	case *parse.WithNode:
+		collectFields(node.Pipe, dotIsRoot, fields)
+		collectFields(node.List, false, fields)
+		collectFields(node.ElseList, dotIsRoot, fields)
+	case *parse.TemplateNode:
+		collectFields(node.Pipe, dotIsRoot, fields)
+	}
+}
@@ -0,0 +1,769 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// A registry is a shared folder or a local git checkout listing templates
+// and their versions in an index file at its root. Registries added with
+// `cradle template add` are recorded in the config directory, and the versions they
+// list are copied into the template cache.
+const (
+	RegistryIndexFileName  = "templates.index.yaml"
+	registriesFileName     = "template_registries.yaml"
+	templateCacheDirName   = "template_cache"
+	templateVersionDivider = "@"
+	// baseVersionsFileName records in a cached template version the versions
+	// of the templates of the same registry it extends or includes.
+	baseVersionsFileName = ".bases.yaml"
+)
+
+// RegistryIndex is the content of a registry's index file.
+type RegistryIndex struct {
+	Templates []RegistryTemplate `yaml:"templates"`
+}
+
+// RegistryTemplate is a template listed in a registry index.
+type RegistryTemplate struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description,omitempty"`
+	Versions    []RegistryVersion `yaml:"versions"`
+}
+
+// RegistryVersion locates one version of a template in the registry.
+type RegistryVersion struct {
+	Version string `yaml:"version"`
+	// Path is the template file or directory, relative to the index file.
+	Path string `yaml:"path"`
+	// Ref is a git commit, tag or branch to read Path at. Without it Path is
+	// read from the registry folder as it is.
+	Ref string `yaml:"ref,omitempty"`
+}
+
+// Registry is a registry recorded in the config directory.
+type Registry struct {
+	// Path is the absolute path of the registry folder.
+	Path      string           `yaml:"path"`
+	Templates []CachedTemplate `yaml:"templates"`
+}
+
+// CachedTemplate lists the cached versions of a registry template.
+type CachedTemplate struct {
+	Name string `yaml:"name"`
+	// Versions are sorted from the latest to the oldest.
+	Versions []string `yaml:"versions"`
+}
+
+type registries struct {
+	Registries []Registry `yaml:"registries"`
+}
+
+// SplitVersion splits a template reference such as go-service@1.2.0 into the
+// template name and the pinned version, empty when none is pinned.
+func SplitVersion(templateName string) (string, string) {
+	name, version, _ := strings.Cut(templateName, templateVersionDivider)
+	return name, version
+}
+
+// JoinVersion returns the reference to version of the template name, the
+// template itself when version is empty.
+func JoinVersion(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + templateVersionDivider + version
+}
+
+// Registries returns the recorded registries.
+func Registries() ([]Registry, error) {
+	recorded, err := readRegistries()
+	return recorded.Registries, err
+}
+
+// AddRegistry records the registry at registryPath and caches every template
+// version it lists. Template names must not be provided by another registry.
+func AddRegistry(registryPath string) (Registry, error) {
+	registryPath, err := filepath.Abs(registryPath)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	recorded, err := readRegistries()
+	if err != nil {
+		return Registry{}, err
+	}
+
+	if slices.ContainsFunc(recorded.Registries, func(r Registry) bool { return r.Path == registryPath }) {
+		return Registry{}, fmt.Errorf("registry %s is already added, use update to refresh it", registryPath)
+	}
+
+	registry, err := cacheRegistry(registryPath, recorded.Registries)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	recorded.Registries = append(recorded.Registries, registry)
+
+	return registry, writeRegistries(recorded)
+}
+
+// UpdateRegistry reads the index of a recorded registry again and refreshes
+// the cached versions. Versions no longer listed are removed from the cache.
+func UpdateRegistry(registryPath string) (Registry, error) {
+	recorded, err := readRegistries()
+	if err != nil {
+		return Registry{}, err
+	}
+
+	i, err := findRegistry(recorded.Registries, registryPath)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	others := slices.Delete(slices.Clone(recorded.Registries), i, i+1)
+
+	registry, err := cacheRegistry(recorded.Registries[i].Path, others)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	for _, cached := range recorded.Registries[i].Templates {
+		if !slices.ContainsFunc(registry.Templates, func(t CachedTemplate) bool { return t.Name == cached.Name }) {
+			err = os.RemoveAll(cachedTemplatePath(cached.Name))
+			if err != nil {
+				return Registry{}, err
+			}
+		}
+	}
+
+	recorded.Registries[i] = registry
+
+	return registry, writeRegistries(recorded)
+}
+
+// RemoveRegistry forgets a recorded registry and deletes its cached templates.
+func RemoveRegistry(registryPath string) (Registry, error) {
+	recorded, err := readRegistries()
+	if err != nil {
+		return Registry{}, err
+	}
+
+	i, err := findRegistry(recorded.Registries, registryPath)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	registry := recorded.Registries[i]
+	for _, cached := range registry.Templates {
+		err = os.RemoveAll(cachedTemplatePath(cached.Name))
+		if err != nil {
+			return Registry{}, err
+		}
+	}
+
+	recorded.Registries = slices.Delete(recorded.Registries, i, i+1)
+
+	return registry, writeRegistries(recorded)
+}
+
+// findRegistry returns the index of the recorded registry at registryPath.
+func findRegistry(recorded []Registry, registryPath string) (int, error) {
+	absPath, err := filepath.Abs(registryPath)
+	if err != nil {
+		return 0, err
+	}
+
+	i := slices.IndexFunc(recorded, func(r Registry) bool { return r.Path == absPath })
+	if i < 0 {
+		return 0, fmt.Errorf("registry %s is not added", absPath)
+	}
+	return i, nil
+}
+
+// ReadRegistryIndex reads and checks the index file of the registry at registryPath.
+func ReadRegistryIndex(registryPath string) (*RegistryIndex, error) {
+	indexPath := filepath.Join(registryPath, RegistryIndexFileName)
+
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s has no %s", registryPath, RegistryIndexFileName)
+		}
+		return nil, err
+	}
+
+	var index RegistryIndex
+
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	yamlDecoder.KnownFields(true)
+
+	err = yamlDecoder.Decode(&index)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse %s: %w", indexPath, err)
+	}
+
+	names := make(map[string]struct{})
+	for _, template := range index.Templates {
+		if template.Name == "" || strings.ContainsAny(template.Name, `/\`+templateVersionDivider) {
+			return nil, fmt.Errorf("%s: invalid template name %q", indexPath, template.Name)
+		}
+		if _, exists := names[template.Name]; exists {
+			return nil, fmt.Errorf("%s: template %s is listed more than once", indexPath, template.Name)
+		}
+		names[template.Name] = struct{}{}
+
+		if len(template.Versions) == 0 {
+			return nil, fmt.Errorf("%s: template %s lists no versions", indexPath, template.Name)
+		}
+
+		versions := make(map[string]struct{})
+		for _, version := range template.Versions {
+			if version.Version == "" || strings.ContainsAny(version.Version, `/\`+templateVersionDivider) || version.Version == "." || version.Version == ".." {
+				return nil, fmt.Errorf("%s: template %s: invalid version %q", indexPath, template.Name, version.Version)
+			}
+			if _, exists := versions[version.Version]; exists {
+				return nil, fmt.Errorf("%s: template %s: version %s is listed more than once", indexPath, template.Name, version.Version)
+			}
+			versions[version.Version] = struct{}{}
+
+			if version.Path != "." {
+				if _, err := cleanFilePath(version.Path); err != nil {
+					return nil, fmt.Errorf("%s: template %s version %s: %w", indexPath, template.Name, version.Version, err)
+				}
+			}
+		}
+	}
+
+	return &index, nil
+}
+
+// cacheRegistry copies every template version listed by the registry into
+// the template cache and returns the registry as it is recorded.
+func cacheRegistry(registryPath string, others []Registry) (Registry, error) {
+	index, err := ReadRegistryIndex(registryPath)
+	if err != nil {
+		return Registry{}, err
+	}
+
+	registry := Registry{Path: registryPath}
+
+	for _, template := range index.Templates {
+		for _, other := range others {
+			if slices.ContainsFunc(other.Templates, func(t CachedTemplate) bool { return t.Name == template.Name }) {
+				return Registry{}, fmt.Errorf("template %s is already provided by registry %s", template.Name, other.Path)
+			}
+		}
+
+		cached := CachedTemplate{Name: template.Name}
+
+		for _, version := range template.Versions {
+			err = cacheVersion(registryPath, index, template.Name, version)
+			if err != nil {
+				return Registry{}, fmt.Errorf("cache template %s version %s: %w", template.Name, version.Version, err)
+			}
+			cached.Versions = append(cached.Versions, version.Version)
+		}
+
+		slices.SortFunc(cached.Versions, func(a, b string) int {
+			return compareVersions(b, a)
+		})
+
+		// Drop versions cached before that are no longer listed.
+		versionDirs, err := os.ReadDir(cachedTemplatePath(template.Name))
+		if err != nil {
+			return Registry{}, err
+		}
+		for _, versionDir := range versionDirs {
+			if !slices.Contains(cached.Versions, versionDir.Name()) {
+				err = os.RemoveAll(filepath.Join(cachedTemplatePath(template.Name), versionDir.Name()))
+				if err != nil {
+					return Registry{}, err
+				}
+			}
+		}
+
+		registry.Templates = append(registry.Templates, cached)
+	}
+
+	return registry, nil
+}
+
+// cacheVersion copies one template version into the cache. The copy is made
+// in a staging directory first, so a failure keeps the version cached before.
+func cacheVersion(registryPath string, index *RegistryIndex, name string, version RegistryVersion) error {
+	cachePath := filepath.Join(cachedTemplatePath(name), version.Version)
+
+	err := os.MkdirAll(filepath.Dir(cachePath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	stagingPath, err := os.MkdirTemp(filepath.Dir(cachePath), ".staging-"+version.Version+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stagingPath)
+
+	// The template is stored under its registry name, so the version directory
+	// is a template source like any user template directory.
+	if version.Ref != "" {
+		err = copyGitTemplate(registryPath, version, stagingPath, name)
+	} else {
+		err = copyLocalTemplate(filepath.Join(registryPath, filepath.FromSlash(version.Path)), stagingPath, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	source := Source{Kind: SourceRegistry, Path: stagingPath, fsys: os.DirFS(stagingPath)}
+	template, err := source.readTemplate(name)
+	if err != nil {
+		return err
+	}
+
+	err = pinBaseVersions(index, template, cachePath, stagingPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(cachePath)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(stagingPath, cachePath)
+}
+
+// pinBaseVersions records the versions of the templates a template version
+// extends or includes that the registry provides, so the version keeps being
+// composed of the same templates when they get newer versions. A base keeps
+// the version recorded when the template version was cached before, as long
+// as it is listed, and gets its latest version otherwise.
+func pinBaseVersions(index *RegistryIndex, template *TemplateData, cachePath, stagingPath string) error {
+	previous, err := readBaseVersions(os.DirFS(cachePath))
+	if err != nil {
+		return err
+	}
+
+	pinned := make(map[string]string)
+
+	for _, baseName := range template.bases() {
+		if _, version := SplitVersion(baseName); version != "" {
+			continue
+		}
+
+		i := slices.IndexFunc(index.Templates, func(t RegistryTemplate) bool { return t.Name == baseName })
+		if i < 0 {
+			continue
+		}
+
+		var versions []string
+		for _, version := range index.Templates[i].Versions {
+			versions = append(versions, version.Version)
+		}
+
+		if version, exists := previous[baseName]; exists && slices.Contains(versions, version) {
+			pinned[baseName] = version
+		} else {
+			pinned[baseName] = slices.MaxFunc(versions, compareVersions)
+		}
+	}
+
+	if len(pinned) == 0 {
+		return nil
+	}
+
+	data, err := yaml.Marshal(pinned)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(stagingPath, baseVersionsFileName), data, 0o644)
+}
+
+// readBaseVersions reads the base versions recorded by pinBaseVersions in a
+// cached template version, none when there are none.
+func readBaseVersions(fsys fs.FS) (map[string]string, error) {
+	versions := make(map[string]string)
+
+	data, err := fs.ReadFile(fsys, baseVersionsFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(data, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", baseVersionsFileName, err)
+	}
+
+	return versions, nil
+}
+
+// copyLocalTemplate copies a template file or directory into dirPath as the template name.
+func copyLocalTemplate(templatePath, dirPath, name string) error {
+	info, err := os.Stat(templatePath)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(templatePath, filepath.Join(dirPath, name+".yaml"), info.Mode().Perm())
+	}
+
+	targetPath := filepath.Join(dirPath, name)
+
+	return filepath.WalkDir(templatePath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && entry.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		relativePath, err := filepath.Rel(templatePath, filePath)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(targetPath, relativePath), 0o755)
+		}
+
+		// Follow symbolic links to the file they point to.
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", filePath)
+		}
+
+		return copyFile(filePath, filepath.Join(targetPath, relativePath), info.Mode().Perm())
+	})
+}
+
+func copyFile(sourcePath, targetPath string, perm os.FileMode) error {
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(targetPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(targetPath, data, perm)
+	if err != nil {
+		return err
+	}
+
+	// WriteFile leaves the permissions of an existing file and applies the umask.
+	return os.Chmod(targetPath, perm)
+}
+
+// copyGitTemplate copies a template file or directory at a git ref of the
+// registry repository into dirPath as the template name, using the git binary.
+// The path "." is the whole repository.
+func copyGitTemplate(repoPath string, version RegistryVersion, dirPath, name string) error {
+	prefix := path.Clean(version.Path)
+
+	// git resolves <ref>:. against the working directory rather than the
+	// root of the tree, so the whole tree is named and archived without a path.
+	object := version.Ref + ":" + prefix
+	archiveArgs := []string{"archive", "--format=tar", version.Ref}
+	if prefix == "." {
+		object = version.Ref + "^{tree}"
+	} else {
+		archiveArgs = append(archiveArgs, "--", prefix)
+	}
+
+	objectType, err := git(repoPath, "cat-file", "-t", object)
+	if err != nil {
+		return fmt.Errorf("%s does not exist at %s: %w", version.Path, version.Ref, err)
+	}
+
+	archive, err := git(repoPath, archiveArgs...)
+	if err != nil {
+		return err
+	}
+
+	targetPath := filepath.Join(dirPath, name)
+	if strings.TrimSpace(string(objectType)) == "blob" {
+		targetPath += ".yaml"
+	}
+
+	return extractArchive(archive, prefix, targetPath)
+}
+
+// extractArchive writes the files below prefix of a tar archive made by git
+// archive to targetPath, the file at prefix itself to targetPath.
+func extractArchive(archive []byte, prefix, targetPath string) error {
+	archiveReader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := archiveReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entryPath := path.Clean(header.Name)
+		if path.IsAbs(entryPath) || entryPath == ".." || strings.HasPrefix(entryPath, "../") {
+			return fmt.Errorf("archive entry %s points outside of the template", header.Name)
+		}
+
+		if prefix != "." {
+			if entryPath == prefix {
+				entryPath = "."
+			} else if rest, found := strings.CutPrefix(entryPath, prefix+"/"); found {
+				entryPath = rest
+			} else {
+				continue
+			}
+		}
+
+		filePath := filepath.Join(targetPath, filepath.FromSlash(entryPath))
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+			if err != nil {
+				return err
+			}
+
+			content, err := io.ReadAll(archiveReader)
+			if err != nil {
+				return err
+			}
+
+			perm := os.FileMode(header.Mode).Perm()
+			err = os.WriteFile(filePath, content, perm)
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s is not a regular file", header.Name)
+		}
+	}
+}
+
+// git runs a git command in the repository and returns its output.
+func git(repoPath string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return output, nil
+}
+
+// registrySources returns a source for the latest cached version of every
+// registry template, in the order the registries were added.
+func registrySources() ([]Source, error) {
+	recorded, err := readRegistries()
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	for _, registry := range recorded.Registries {
+		for _, cached := range registry.Templates {
+			if len(cached.Versions) == 0 {
+				continue
+			}
+			sources = append(sources, registrySource(registry, cached.Name, cached.Versions[0]))
+		}
+	}
+
+	return sources, nil
+}
+
+// findPinnedTemplate returns the source holding a cached template version.
+func findPinnedTemplate(name, version string) (Source, error) {
+	recorded, err := readRegistries()
+	if err != nil {
+		return Source{}, err
+	}
+
+	for _, registry := range recorded.Registries {
+		for _, cached := range registry.Templates {
+			if cached.Name != name {
+				continue
+			}
+
+			if !slices.Contains(cached.Versions, version) {
+				return Source{}, fmt.Errorf("template %s has no version %s, available: %s",
+					name, version, strings.Join(cached.Versions, ", "))
+			}
+
+			return registrySource(registry, name, version), nil
+		}
+	}
+
+	return Source{}, fmt.Errorf("template %s is not provided by any registry, only registry templates can be pinned to a version", name)
+}
+
+func registrySource(registry Registry, name, version string) Source {
+	cachePath := filepath.Join(cachedTemplatePath(name), version)
+
+	return Source{
+		Kind:     SourceRegistry,
+		Path:     cachePath,
+		Registry: registry.Path,
+		Version:  version,
+		fsys:     os.DirFS(cachePath),
+	}
+}
+
+// compareVersions orders versions such as v1.2.0, 1.10 and 2.0.0-rc.1 the
+// way semantic versioning does: by their numeric core first, then a
+// pre-release below its release. Build metadata after "+" does not affect
+// the order, versions equal otherwise are ordered as text to keep the order
+// stable.
+func compareVersions(a, b string) int {
+	aCore, aPreRelease := splitVersion(a)
+	bCore, bPreRelease := splitVersion(b)
+
+	result := compareIdentifiers(aCore, bCore, "0")
+	if result != 0 {
+		return result
+	}
+
+	// A release ranks above its pre-releases.
+	switch {
+	case aPreRelease == nil && bPreRelease != nil:
+		return 1
+	case aPreRelease != nil && bPreRelease == nil:
+		return -1
+	}
+
+	result = compareIdentifiers(aPreRelease, bPreRelease, "")
+	if result != 0 {
+		return result
+	}
+
+	return strings.Compare(a, b)
+}
+
+// splitVersion splits a version into the dot separated parts of its core and
+// of its pre-release, nil without one, dropping the "v" prefix and build metadata.
+func splitVersion(version string) (core, preRelease []string) {
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "+")
+
+	version, preReleaseText, hasPreRelease := strings.Cut(version, "-")
+	if hasPreRelease {
+		preRelease = strings.Split(preReleaseText, ".")
+	}
+
+	return strings.Split(version, "."), preRelease
+}
+
+// compareIdentifiers compares dot separated version parts one by one:
+// numbers by value and below text, text by its characters. A missing part
+// counts as missing, or ranks below any part when missing is empty.
+func compareIdentifiers(a, b []string, missing string) int {
+	for i := range max(len(a), len(b)) {
+		aPart, bPart := missing, missing
+		if i < len(a) {
+			aPart = a[i]
+		}
+		if i < len(b) {
+			bPart = b[i]
+		}
+
+		if aPart == "" || bPart == "" {
+			if result := strings.Compare(aPart, bPart); result != 0 {
+				return result
+			}
+			continue
+		}
+
+		aNumber, aErr := strconv.Atoi(aPart)
+		bNumber, bErr := strconv.Atoi(bPart)
+
+		var result int
+		switch {
+		case aErr == nil && bErr == nil:
+			result = aNumber - bNumber
+		case aErr == nil:
+			result = -1
+		case bErr == nil:
+			result = 1
+		default:
+			result = strings.Compare(aPart, bPart)
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return 0
+}
+
+func readRegistries() (registries, error) {
+	var recorded registries
+
+	data, err := os.ReadFile(registriesFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return recorded, nil
+		}
+		return recorded, err
+	}
+
+	err = yaml.Unmarshal(data, &recorded)
+	if err != nil {
+		return recorded, fmt.Errorf("parse %s: %w", registriesFilePath(), err)
+	}
+
+	return recorded, nil
+}
+
+func writeRegistries(recorded registries) error {
+	data, err := yaml.Marshal(recorded)
+	if err != nil {
+		return err
+	}
+
+	return config.WriteFileAtomic(registriesFilePath(), data, 0o644)
+}
+
+func registriesFilePath() string {
+	return filepath.Join(config.Get().ConfigDirPath, registriesFileName)
+}
+
+func cachedTemplatePath(name string) string {
+	return filepath.Join(config.Get().CacheDirPath, templateCacheDirName, name)
+}
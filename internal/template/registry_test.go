@@ -0,0 +1,401 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"v1.2.0", "1.2.0", 1},
+		{"1.2.0", "1.10.0", -1},
+		{"1.10", "1.9.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-rc.1", "0.9.0", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0+build.2", "1.0.0-rc1", 1},
+		{"1.0.0+build.2", "1.0.1", -1},
+		{"1.0.0-rc1+build", "1.0.0", -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			got := compareVersions(test.a, test.b)
+			if sign(got) != test.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want sign %d", test.a, test.b, got, test.want)
+			}
+			if sign(compareVersions(test.b, test.a)) != -test.want {
+				t.Errorf("compareVersions(%q, %q) is not the reverse of compareVersions(%q, %q)", test.b, test.a, test.a, test.b)
+			}
+		})
+	}
+}
+
+func TestCompareVersionsSort(t *testing.T) {
+	versions := []string{"1.0.0", "1.0.0-rc.10", "0.9.0", "1.0.0-alpha", "1.1.0", "1.0.0-rc.2", "1.0.0-beta"}
+	want := []string{"0.9.0", "1.0.0-alpha", "1.0.0-beta", "1.0.0-rc.2", "1.0.0-rc.10", "1.0.0", "1.1.0"}
+
+	slices.SortFunc(versions, compareVersions)
+
+	if !slices.Equal(versions, want) {
+		t.Errorf("sorted versions = %v, want %v", versions, want)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestPinBaseVersions(t *testing.T) {
+	index := &RegistryIndex{Templates: []RegistryTemplate{
+		{Name: "base", Versions: []RegistryVersion{{Version: "1.0.0"}, {Version: "1.2.0"}, {Version: "1.1.0"}}},
+		{Name: "svc", Versions: []RegistryVersion{{Version: "1.0.0"}}},
+	}}
+	template := &TemplateData{Include: []string{"base", "gitignore-go", "license@1.0.0"}}
+
+	tests := []struct {
+		name string
+		// previous is the base versions file of the version cached before, if any.
+		previous string
+		want     map[string]string
+	}{
+		{
+			name: "latest version when first cached",
+			want: map[string]string{"base": "1.2.0"},
+		},
+		{
+			name:     "recorded version is kept",
+			previous: "base: 1.0.0\n",
+			want:     map[string]string{"base": "1.0.0"},
+		},
+		{
+			name:     "recorded version no longer listed",
+			previous: "base: 0.9.0\n",
+			want:     map[string]string{"base": "1.2.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cachePath := t.TempDir()
+			stagingPath := t.TempDir()
+
+			if test.previous != "" {
+				err := os.WriteFile(filepath.Join(cachePath, baseVersionsFileName), []byte(test.previous), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := pinBaseVersions(index, template, cachePath, stagingPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readBaseVersions(os.DirFS(stagingPath))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !maps.Equal(got, test.want) {
+				t.Errorf("pinned base versions = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// writeTestFiles writes the files, by slash separated path, below dirPath.
+func writeTestFiles(t *testing.T, dirPath string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		filePath := filepath.Join(dirPath, filepath.FromSlash(name))
+
+		err := os.MkdirAll(filepath.Dir(filePath), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filePath, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRegistryLifecycle(t *testing.T) {
+	initTestTemplates(t, nil)
+
+	registryPath := t.TempDir()
+	writeTestFiles(t, registryPath, map[string]string{
+		RegistryIndexFileName: `templates:
+  - name: svc
+    versions:
+      - {version: 1.0.0, path: svc-1.0.0.yaml}
+      - {version: 1.1.0, path: svc-1.1.0}
+`,
+		"svc-1.0.0.yaml":                 "version: v1\nname: svc\nfiles:\n  VERSION: \"1.0.0\\n\"\n",
+		"svc-1.1.0/template.yaml":        "version: v1\nname: svc\n",
+		"svc-1.1.0/files/VERSION":        "1.1.0\n",
+		"svc-1.1.0/files/README.md.tmpl": "# {{.ProjectName}}\n",
+	})
+
+	registry, err := AddRegistry(registryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CachedTemplate{{Name: "svc", Versions: []string{"1.1.0", "1.0.0"}}}
+	if !slices.EqualFunc(registry.Templates, want, equalCachedTemplates) {
+		t.Errorf("added templates = %v, want %v", registry.Templates, want)
+	}
+
+	wantVersions := map[string]string{"svc": "1.1.0\n", "svc@1.0.0": "1.0.0\n"}
+	for ref, want := range wantVersions {
+		td, err := GetTemplate(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := td.Files["VERSION"].Content; got != want {
+			t.Errorf("%s VERSION = %q, want %q", ref, got, want)
+		}
+	}
+
+	if _, err := AddRegistry(registryPath); err == nil {
+		t.Error("AddRegistry() of an added registry succeeded, want an error")
+	}
+
+	// 1.0.0 is no longer listed and a template is added.
+	writeTestFiles(t, registryPath, map[string]string{
+		RegistryIndexFileName: `templates:
+  - name: svc
+    versions:
+      - {version: 1.1.0, path: svc-1.1.0}
+  - name: lib
+    versions:
+      - {version: 0.1.0, path: lib.yaml}
+`,
+		"lib.yaml": "version: v1\nname: lib\n",
+	})
+
+	registry, err = UpdateRegistry(registryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []CachedTemplate{{Name: "svc", Versions: []string{"1.1.0"}}, {Name: "lib", Versions: []string{"0.1.0"}}}
+	if !slices.EqualFunc(registry.Templates, want, equalCachedTemplates) {
+		t.Errorf("updated templates = %v, want %v", registry.Templates, want)
+	}
+	if _, err := os.Stat(filepath.Join(cachedTemplatePath("svc"), "1.0.0")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("version no longer listed is still cached: %v", err)
+	}
+
+	_, err = RemoveRegistry(registryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registries, err := Registries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registries) != 0 {
+		t.Errorf("registries = %v, want none", registries)
+	}
+	for _, name := range []string{"svc", "lib"} {
+		if _, err := os.Stat(cachedTemplatePath(name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("template %s of the removed registry is still cached: %v", name, err)
+		}
+		if _, err := GetTemplate(name); !errors.Is(err, ErrNotExists) {
+			t.Errorf("GetTemplate(%q) error = %v, want %v", name, err, ErrNotExists)
+		}
+	}
+}
+
+func equalCachedTemplates(a, b CachedTemplate) bool {
+	return a.Name == b.Name && slices.Equal(a.Versions, b.Versions)
+}
+
+func TestCacheVersionKeepsCachedVersionOnFailure(t *testing.T) {
+	initTestTemplates(t, nil)
+
+	registryPath := t.TempDir()
+	writeTestFiles(t, registryPath, map[string]string{
+		"svc.yaml": "version: v1\nname: svc\nfiles:\n  VERSION: \"1.0.0\\n\"\n",
+		"bad.yaml": "version: v1\nname: svc\nunknown: field\n",
+	})
+	index := &RegistryIndex{}
+
+	err := cacheVersion(registryPath, index, "svc", RegistryVersion{Version: "1.0.0", Path: "svc.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cacheVersion(registryPath, index, "svc", RegistryVersion{Version: "1.0.0", Path: "bad.yaml"})
+	if err == nil {
+		t.Fatal("cacheVersion() of an invalid template succeeded, want an error")
+	}
+
+	cachePath := filepath.Join(cachedTemplatePath("svc"), "1.0.0")
+	content, err := os.ReadFile(filepath.Join(cachePath, "svc.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "version: v1\nname: svc\nfiles:\n  VERSION: \"1.0.0\\n\"\n"; string(content) != want {
+		t.Errorf("cached template = %q, want %q", content, want)
+	}
+
+	entries, err := os.ReadDir(cachedTemplatePath("svc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("cache entries = %v, want only the cached version", entries)
+	}
+}
+
+func TestCopyGitTemplate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoPath := t.TempDir()
+	writeTestFiles(t, repoPath, map[string]string{
+		"template.yaml":       "version: v1\nname: root\n",
+		"files/README.md":     "root\n",
+		"templates/svc.yaml":  "version: v1\nname: svc\n",
+		"templates/lib/x.txt": "lib\n",
+	})
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "templates"},
+		{"tag", "v1"},
+	} {
+		if _, err := git(repoPath, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		// want are the copied files by slash separated path.
+		want map[string]string
+	}{
+		{
+			name: "file",
+			path: "templates/svc.yaml",
+			want: map[string]string{"app.yaml": "version: v1\nname: svc\n"},
+		},
+		{
+			name: "directory",
+			path: "templates/lib",
+			want: map[string]string{"app/x.txt": "lib\n"},
+		},
+		{
+			name: "repository root",
+			path: ".",
+			want: map[string]string{
+				"app/template.yaml":       "version: v1\nname: root\n",
+				"app/files/README.md":     "root\n",
+				"app/templates/svc.yaml":  "version: v1\nname: svc\n",
+				"app/templates/lib/x.txt": "lib\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dirPath := t.TempDir()
+
+			err := copyGitTemplate(repoPath, RegistryVersion{Version: "1.0.0", Path: test.path, Ref: "v1"}, dirPath, "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string)
+			err = filepath.WalkDir(dirPath, func(filePath string, entry os.DirEntry, err error) error {
+				if err != nil || entry.IsDir() {
+					return err
+				}
+
+				content, err := os.ReadFile(filePath)
+				if err != nil {
+					return err
+				}
+				relativePath, err := filepath.Rel(dirPath, filePath)
+				if err != nil {
+					return err
+				}
+
+				got[filepath.ToSlash(relativePath)] = string(content)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !maps.Equal(got, test.want) {
+				t.Errorf("copied files = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveOutsidePaths(t *testing.T) {
+	tests := []string{"../escape.txt", "templates/../../escape.txt", "/etc/escape.txt", ".."}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			archiveWriter := tar.NewWriter(&buf)
+			err := archiveWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := archiveWriter.Write([]byte("out\n")); err != nil {
+				t.Fatal(err)
+			}
+			if err := archiveWriter.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			dirPath := t.TempDir()
+			targetPath := filepath.Join(dirPath, "template", "app")
+
+			err = extractArchive(buf.Bytes(), ".", targetPath)
+			if err == nil {
+				t.Error("extractArchive() succeeded, want entries outside the template to be refused")
+			}
+			if _, err := os.Stat(filepath.Join(dirPath, "escape.txt")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("file outside the template was written: %v", err)
+			}
+		})
+	}
+}
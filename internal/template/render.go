@@ -0,0 +1,139 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/gurleensethi/cradle/internal/types"
+)
+
+// RenderedFile is a template file rendered with the user inputs.
+type RenderedFile struct {
+	// Path is the slash separated path of the file relative to the project root.
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// Render renders every file of the template with the given inputs and
+// returns the files sorted by path. File paths are rendered as templates too;
+// files whose `when` condition is false or whose path renders empty are skipped.
+// now, year and uuid return the values recorded in generated, which records
+// the new ones; generated may be nil.
+func Render(td *TemplateData, inputs map[string]any, generated *types.Generated) ([]RenderedFile, error) {
+	var files []RenderedFile
+	renderedFrom := make(map[string]string)
+	gen := newGenerator(generated)
+
+	for _, filePath := range slices.Sorted(maps.Keys(td.Files)) {
+		templateFile := td.Files[filePath]
+		funcMap := gen.funcMap(filePath)
+
+		if templateFile.When != "" {
+			include, err := evalCondition(filePath, templateFile.When, inputs, funcMap)
+			if err != nil {
+				return nil, fmt.Errorf("render file %s: when: %w", filePath, err)
+			}
+			if !include {
+				continue
+			}
+		}
+
+		renderedPath, err := renderString(filePath, filePath, inputs, funcMap)
+		if err != nil {
+			return nil, fmt.Errorf("render path of file %s: %w", filePath, err)
+		}
+
+		renderedPath = strings.TrimSpace(renderedPath)
+		if renderedPath == "" {
+			continue
+		}
+
+		cleanedPath, err := cleanFilePath(renderedPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if otherPath, exists := renderedFrom[cleanedPath]; exists {
+			return nil, fmt.Errorf("files %s and %s both render to %s", otherPath, filePath, cleanedPath)
+		}
+		renderedFrom[cleanedPath] = filePath
+
+		content, err := renderContent(filePath, templateFile, inputs, funcMap)
+		if err != nil {
+			return nil, fmt.Errorf("render file %s: %w", filePath, err)
+		}
+
+		files = append(files, RenderedFile{
+			Path:    cleanedPath,
+			Content: content,
+			Mode:    templateFile.Perm(),
+		})
+	}
+
+	slices.SortFunc(files, func(a, b RenderedFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return files, nil
+}
+
+// renderContent renders the content of a template file. Encoded and
+// verbatim files are copied as they are.
+func renderContent(name string, templateFile TemplateFile, data any, funcMap template.FuncMap) ([]byte, error) {
+	switch templateFile.Encoding {
+	case "":
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(templateFile.Content)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", templateFile.Encoding)
+	}
+
+	if templateFile.Verbatim {
+		return []byte(templateFile.Content), nil
+	}
+
+	content, err := renderString(name, templateFile.Content, data, funcMap)
+	return []byte(content), err
+}
+
+// renderString renders text as a template named name with the functions of funcMap.
+func renderString(name, text string, data any, funcMap template.FuncMap) (string, error) {
+	t, err := template.New(name).Funcs(funcMap).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+
+	err = t.Execute(buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// evalCondition evaluates a template pipeline such as `.WithDocker` or
+// `eq .Kind "http"` and reports whether the result is truthy, following the
+// rules of the `if` action.
+func evalCondition(name, condition string, data any, funcMap template.FuncMap) (bool, error) {
+	result, err := renderString(name, conditionTemplate(condition), data, funcMap)
+	if err != nil {
+		return false, err
+	}
+
+	return result == "true", nil
+}
+
+// conditionTemplate wraps a condition pipeline into a template that renders
+// "true" when the condition holds.
+func conditionTemplate(condition string) string {
+	return "{{if " + condition + "}}true{{end}}"
+}
@@ -0,0 +1,82 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderPathsAndConditions(t *testing.T) {
+	td := &TemplateData{
+		Name: "service",
+		Files: map[string]TemplateFile{
+			"README.md":                    {Content: "# {{.ProjectName}}\n"},
+			"cmd/{{.ProjectName}}/main.go": {Content: "package main\n"},
+			"Dockerfile":                   {Content: "FROM scratch\n", When: `eq .Docker "yes"`},
+			`{{if eq .Docker "yes"}}compose.yaml{{end}}`: {Content: "services: {}\n"},
+		},
+	}
+
+	tests := []struct {
+		docker    string
+		wantPaths []string
+	}{
+		{docker: "yes", wantPaths: []string{"Dockerfile", "README.md", "cmd/api/main.go", "compose.yaml"}},
+		{docker: "no", wantPaths: []string{"README.md", "cmd/api/main.go"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.docker, func(t *testing.T) {
+			files, err := Render(td, map[string]any{"ProjectName": "api", "Docker": test.docker}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var paths []string
+			for _, file := range files {
+				paths = append(paths, file.Path)
+			}
+			if !slices.Equal(paths, test.wantPaths) {
+				t.Errorf("rendered paths = %v, want %v", paths, test.wantPaths)
+			}
+		})
+	}
+}
+
+func TestRenderPathErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]TemplateFile
+		wantErr string
+	}{
+		{
+			name:    "outside the project",
+			files:   map[string]TemplateFile{"{{.Dir}}/main.go": {Content: "package main\n"}},
+			wantErr: "must stay inside the project directory",
+		},
+		{
+			name: "same rendered path",
+			files: map[string]TemplateFile{
+				"{{.ProjectName}}.go": {Content: "package a\n"},
+				"api.go":              {Content: "package b\n"},
+			},
+			wantErr: "both render to api.go",
+		},
+		{
+			name:    "invalid condition",
+			files:   map[string]TemplateFile{"main.go": {Content: "package main\n", When: "eq .Docker"}},
+			wantErr: "when",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			td := &TemplateData{Name: "broken", Files: test.files}
+
+			_, err := Render(td, map[string]any{"ProjectName": "api", "Dir": "..", "Docker": "yes"}, nil)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Render() error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
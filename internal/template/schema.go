@@ -0,0 +1,174 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the version of the template format this cradle
+// reads and writes, declared in templates as `version: v1`.
+const CurrentSchemaVersion = "v1"
+
+// schemaMigration upgrades a template document from one schema version to the next.
+type schemaMigration struct {
+	From, To string
+	// Migrate rewrites the top level mapping of the template document in place.
+	Migrate func(root *yaml.Node) error
+}
+
+// schemaMigrations upgrade templates written for older schema versions step
+// by step until they reach CurrentSchemaVersion. A change to the template
+// format bumps CurrentSchemaVersion and adds a migration from the previous
+// version, so existing templates keep working.
+var schemaMigrations []schemaMigration
+
+// parseTemplate decodes a YAML template definition. Templates of older schema
+// versions are migrated first, and unknown fields are rejected.
+func parseTemplate(data []byte) (*TemplateData, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, errors.New("template is empty")
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: template must be a mapping", root.Line)
+	}
+
+	var warnings []string
+
+	versionNode := mappingValue(root, "version")
+	if versionNode == nil {
+		warnings = append(warnings, fmt.Sprintf("template declares no version, assuming `version: %s`", CurrentSchemaVersion))
+	} else if versionNode.Value != CurrentSchemaVersion {
+		err := migrateSchema(root, versionNode)
+		if err != nil {
+			return nil, err
+		}
+
+		// Line numbers in errors refer to the migrated template from here on.
+		data, err = yaml.Marshal(root)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var template TemplateData
+
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(data))
+	yamlDecoder.KnownFields(true)
+
+	err := yamlDecoder.Decode(&template)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Version = CurrentSchemaVersion
+
+	for i, input := range template.Inputs {
+		if !slices.Contains(InputTypes, input.Kind()) {
+			return nil, fmt.Errorf("input %s: unknown type %q, expected one of: %s",
+				inputLabel(i, input), input.Type, strings.Join(InputTypes, ", "))
+		}
+
+		if input.Pattern != "" {
+			warnings = append(warnings, fmt.Sprintf("input %s: `pattern` has no effect, use `validate.pattern` instead", inputLabel(i, input)))
+		}
+	}
+
+	template.Warnings = warnings
+
+	return &template, nil
+}
+
+// migrateSchema applies the migrations from the declared version up to
+// CurrentSchemaVersion, updating versionNode along the way.
+func migrateSchema(root, versionNode *yaml.Node) error {
+	for versionNode.Value != CurrentSchemaVersion {
+		i := slices.IndexFunc(schemaMigrations, func(migration schemaMigration) bool {
+			return migration.From == versionNode.Value
+		})
+		if i < 0 {
+			return fmt.Errorf("line %d: unsupported template version %q, this cradle reads %s; upgrade cradle or fix the version",
+				versionNode.Line, versionNode.Value, strings.Join(supportedSchemaVersions(), ", "))
+		}
+
+		migration := schemaMigrations[i]
+
+		err := migration.Migrate(root)
+		if err != nil {
+			return fmt.Errorf("migrate template from %s to %s: %w", migration.From, migration.To, err)
+		}
+
+		versionNode.Value = migration.To
+	}
+
+	return nil
+}
+
+// supportedSchemaVersions lists every schema version templates can declare.
+func supportedSchemaVersions() []string {
+	var versions []string
+	for _, migration := range schemaMigrations {
+		versions = append(versions, migration.From)
+	}
+	return append(versions, CurrentSchemaVersion)
+}
+
+// mappingValue returns the value of key in a mapping node, nil if it is not set.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkKnownFields reports keys of a mapping node that are not fields of the
+// struct target points to. Node.Decode does not inherit the strict mode of
+// the decoder, so custom unmarshalers check their fields with it.
+func checkKnownFields(mapping *yaml.Node, target any) error {
+	structType := reflect.TypeOf(target).Elem()
+
+	known := make(map[string]struct{})
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		known[name] = struct{}{}
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if _, exists := known[key.Value]; !exists {
+			return fmt.Errorf("line %d: field %s not found in type %s", key.Line, key.Value, structType)
+		}
+	}
+
+	return nil
+}
+
+// inputLabel names an input in messages, by position when it has no name.
+func inputLabel(i int, input TemplateInput) string {
+	if input.Name == "" {
+		return fmt.Sprintf("#%d", i+1)
+	}
+	return input.Name
+}
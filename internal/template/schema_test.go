@@ -0,0 +1,122 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// wantWarning is part of the only warning, wantErr part of the error.
+		wantWarning string
+		wantErr     string
+	}{
+		{
+			name: "current version",
+			data: "version: v1\nname: app\nfiles:\n  a.txt: {content: a, mode: 0600}\n",
+		},
+		{
+			name:        "no version",
+			data:        "name: app\n",
+			wantWarning: "template declares no version",
+		},
+		{
+			name:    "unsupported version",
+			data:    "version: v9\nname: app\n",
+			wantErr: `line 1: unsupported template version "v9", this cradle reads v1`,
+		},
+		{
+			name:    "unknown field",
+			data:    "version: v1\nname: app\nfile:\n  a.txt: a\n",
+			wantErr: "field file not found",
+		},
+		{
+			name:    "unknown file field",
+			data:    "version: v1\nname: app\nfiles:\n  a.txt: {content: a, executabel: true}\n",
+			wantErr: "line 4: field executabel not found",
+		},
+		{
+			name:    "unknown input type",
+			data:    "version: v1\nname: app\ninputs:\n  - name: Port\n    type: port\n",
+			wantErr: `input Port: unknown type "port"`,
+		},
+		{
+			name:        "input pattern",
+			data:        "version: v1\nname: app\ninputs:\n  - pattern: ^a\n",
+			wantWarning: "input #1: `pattern` has no effect",
+		},
+		{
+			name:    "empty",
+			data:    "",
+			wantErr: "template is empty",
+		},
+		{
+			name:    "not a mapping",
+			data:    "- name: app\n",
+			wantErr: "line 1: template must be a mapping",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			template, err := parseTemplate([]byte(test.data))
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("parseTemplate() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if template.Version != CurrentSchemaVersion {
+				t.Errorf("version = %q, want %q", template.Version, CurrentSchemaVersion)
+			}
+
+			switch {
+			case test.wantWarning == "" && len(template.Warnings) != 0:
+				t.Errorf("warnings = %v, want none", template.Warnings)
+			case test.wantWarning != "" && (len(template.Warnings) != 1 || !strings.Contains(template.Warnings[0], test.wantWarning)):
+				t.Errorf("warnings = %v, want %q", template.Warnings, test.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParseTemplateMigratesSchema(t *testing.T) {
+	migrations := schemaMigrations
+	t.Cleanup(func() { schemaMigrations = migrations })
+
+	// v0 called the files of a template `paths`.
+	schemaMigrations = []schemaMigration{{
+		From: "v0",
+		To:   CurrentSchemaVersion,
+		Migrate: func(root *yaml.Node) error {
+			for i := 0; i < len(root.Content); i += 2 {
+				if root.Content[i].Value == "paths" {
+					root.Content[i].Value = "files"
+				}
+			}
+			return nil
+		},
+	}}
+
+	template, err := parseTemplate([]byte("version: v0\nname: app\npaths:\n  a.txt: a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := template.Files["a.txt"].Content; got != "a" {
+		t.Errorf("a.txt = %q, want the file of the v0 template", got)
+	}
+
+	_, err = parseTemplate([]byte("version: v2\nname: app\n"))
+	if err == nil || !strings.Contains(err.Error(), "this cradle reads v0, v1") {
+		t.Errorf("parseTemplate() error = %v, want the supported versions", err)
+	}
+}
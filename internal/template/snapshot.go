@@ -0,0 +1,293 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EncodingBase64 marks a template file whose content is base64 encoded. Such
+// files are decoded and copied as they are, without being rendered.
+const EncodingBase64 = "base64"
+
+// SnapshotVariable turns a literal string of a project into a template input.
+type SnapshotVariable struct {
+	// Input is the name of the input replacing the literal, e.g. ModuleName.
+	Input string
+	// Literal is the text in the project that is replaced with `{{.Input}}`.
+	Literal string
+	// Type is the type of the declared input, defaults to string.
+	Type string
+}
+
+// SnapshotOptions controls how a project is turned into a template.
+type SnapshotOptions struct {
+	Name        string
+	Description string
+	// Exclude are .gitignore style patterns of files to leave out, in
+	// addition to the project's .gitignore.
+	Exclude []string
+	// EncodeBinary stores binary files base64 encoded instead of skipping them.
+	EncodeBinary bool
+	Variables    []SnapshotVariable
+}
+
+// SnapshotResult is a template created from a project.
+type SnapshotResult struct {
+	Template *TemplateData
+	// Skipped lists the files that were not added to the template and why.
+	Skipped []string
+	// Replacements lists every literal replaced with an input placeholder.
+	Replacements []SnapshotReplacement
+}
+
+// SnapshotReplacement is an occurrence of a literal replaced with the
+// placeholder of its input.
+type SnapshotReplacement struct {
+	// File is the slash separated path of the file in the project.
+	File string
+	// Line is the line of the file the literal is on, 0 when it is in the path.
+	Line     int
+	Variable SnapshotVariable
+}
+
+// Snapshot walks the project directory and creates a template reproducing
+// its files. Ignored files and the .git directory are left out, and literal
+// strings listed in opts.Variables are replaced with input placeholders.
+func Snapshot(projectPath string, opts SnapshotOptions) (*SnapshotResult, error) {
+	rules := parseIgnoreRules([]byte(".git/\n" + strings.Join(opts.Exclude, "\n")))
+
+	gitignore, err := os.ReadFile(filepath.Join(projectPath, ".gitignore"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	rules = append(rules, parseIgnoreRules(gitignore)...)
+
+	// Replace longer literals first, they may contain shorter ones.
+	variables := slices.Clone(opts.Variables)
+	slices.SortStableFunc(variables, func(a, b SnapshotVariable) int {
+		return len(b.Literal) - len(a.Literal)
+	})
+
+	result := &SnapshotResult{
+		Template: &TemplateData{
+			Version:     CurrentSchemaVersion,
+			Name:        opts.Name,
+			Description: opts.Description,
+			Files:       make(map[string]TemplateFile),
+		},
+	}
+
+	for _, variable := range opts.Variables {
+		if slices.Contains(builtinInputs, variable.Input) {
+			continue
+		}
+
+		result.Template.Inputs = append(result.Template.Inputs, TemplateInput{
+			Name:        variable.Input,
+			Type:        variable.Type,
+			Required:    true,
+			Description: fmt.Sprintf("Replaces %q of the original project.", variable.Literal),
+		})
+	}
+
+	err = filepath.WalkDir(projectPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(projectPath, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if relPath == "." {
+			return nil
+		}
+
+		if rules.match(relPath, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			result.Skipped = append(result.Skipped, relPath+": not a regular file")
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		templateFile := TemplateFile{
+			Executable: info.Mode().Perm()&0o111 != 0,
+		}
+
+		if isBinary(content) {
+			if !opts.EncodeBinary {
+				result.Skipped = append(result.Skipped, relPath+": binary file")
+				return nil
+			}
+
+			templateFile.Content = base64.StdEncoding.EncodeToString(content)
+			templateFile.Encoding = EncodingBase64
+		} else {
+			var replacements []SnapshotReplacement
+			templateFile.Content, replacements = parameterize(string(content), variables)
+
+			for _, replacement := range replacements {
+				replacement.File = relPath
+				result.Replacements = append(result.Replacements, replacement)
+			}
+		}
+
+		templatePath, replacements := parameterize(relPath, variables)
+		for _, replacement := range replacements {
+			replacement.File = relPath
+			replacement.Line = 0
+			result.Replacements = append(result.Replacements, replacement)
+		}
+
+		result.Template.Files[templatePath] = templateFile
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SnapshotCandidates suggests literal strings of the project that are
+// likely to differ between projects: its name, Go module path and port.
+func SnapshotCandidates(projectPath string) []SnapshotVariable {
+	candidates := []SnapshotVariable{
+		{Input: "ProjectName", Literal: filepath.Base(projectPath)},
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(projectPath, "go.mod"))
+	if err == nil {
+		if match := goModulePattern.FindSubmatch(goMod); match != nil {
+			candidates = append(candidates, SnapshotVariable{Input: "ModuleName", Literal: string(match[1])})
+		}
+	}
+
+	for _, fileName := range []string{"main.go", ".env", "Dockerfile", "docker-compose.yml"} {
+		content, err := os.ReadFile(filepath.Join(projectPath, fileName))
+		if err != nil {
+			continue
+		}
+
+		if match := portPattern.FindSubmatch(content); match != nil {
+			candidates = append(candidates, SnapshotVariable{Input: "Port", Literal: string(match[1]), Type: InputTypeInt})
+			break
+		}
+	}
+
+	return candidates
+}
+
+var (
+	goModulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	portPattern     = regexp.MustCompile(`(?i)(?:port\s*[=:]\s*|:)"?(\d{4,5})\b`)
+)
+
+// parameterize escapes template actions in text and replaces every literal
+// with the placeholder of its input. Literals only match whole identifiers,
+// so the project name "api" is replaced in "api.Handler" but not in
+// "apiClient" or "my-api-client". variables are tried in order, longer
+// literals must come first. It returns the text and the replacements made,
+// with their line.
+func parameterize(text string, variables []SnapshotVariable) (string, []SnapshotReplacement) {
+	var out strings.Builder
+	var replacements []SnapshotReplacement
+	line := 1
+
+	for i := 0; i < len(text); {
+		// Keep literal "{{" in the project from being parsed as actions.
+		if strings.HasPrefix(text[i:], "{{") {
+			out.WriteString(`{{"{{"}}`)
+			i += 2
+			continue
+		}
+
+		if variable, found := literalAt(text, i, variables); found {
+			out.WriteString("{{." + variable.Input + "}}")
+			replacements = append(replacements, SnapshotReplacement{Line: line, Variable: variable})
+			line += strings.Count(variable.Literal, "\n")
+			i += len(variable.Literal)
+			continue
+		}
+
+		if text[i] == '\n' {
+			line++
+		}
+		out.WriteByte(text[i])
+		i++
+	}
+
+	return out.String(), replacements
+}
+
+// literalAt returns the first variable whose literal is at position i of
+// text without being part of a longer identifier.
+func literalAt(text string, i int, variables []SnapshotVariable) (SnapshotVariable, bool) {
+	for _, variable := range variables {
+		literal := variable.Literal
+		if literal == "" || !strings.HasPrefix(text[i:], literal) {
+			continue
+		}
+
+		first, _ := utf8.DecodeRuneInString(literal)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		if i > 0 && isIdentifierRune(first) && isIdentifierRune(before) {
+			continue
+		}
+
+		end := i + len(literal)
+		last, _ := utf8.DecodeLastRuneInString(literal)
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if end < len(text) && isIdentifierRune(last) && isIdentifierRune(after) {
+			continue
+		}
+
+		return variable, true
+	}
+
+	return SnapshotVariable{}, false
+}
+
+// isIdentifierRune reports whether r can be part of an identifier. Dashes
+// count, since project names are often written in kebab case.
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isBinary reports whether content looks like binary data rather than text.
+func isBinary(content []byte) bool {
+	sample := content[:min(len(content), 8000)]
+	return bytes.IndexByte(sample, 0) >= 0 || !utf8.Valid(content)
+}
@@ -0,0 +1,248 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParameterize(t *testing.T) {
+	variables := []SnapshotVariable{
+		{Input: "ModuleName", Literal: "github.com/acme/api"},
+		{Input: "ProjectName", Literal: "api"},
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantLines []int
+	}{
+		{
+			name:      "whole word",
+			text:      "package api\n",
+			want:      "package {{.ProjectName}}\n",
+			wantLines: []int{1},
+		},
+		{
+			name:      "longer literal first",
+			text:      "module github.com/acme/api\n",
+			want:      "module {{.ModuleName}}\n",
+			wantLines: []int{1},
+		},
+		{
+			name: "part of identifiers",
+			text: "apiClient := newapi()\nrapid\napi_key\nmy-api-client\napi2\n",
+			want: "apiClient := newapi()\nrapid\napi_key\nmy-api-client\napi2\n",
+		},
+		{
+			name:      "between separators",
+			text:      "x := api.New()\nroute(\"/api/\")\n\"api\"\n",
+			want:      "x := {{.ProjectName}}.New()\nroute(\"/{{.ProjectName}}/\")\n\"{{.ProjectName}}\"\n",
+			wantLines: []int{1, 2, 3},
+		},
+		{
+			name: "other case",
+			text: "API\n",
+			want: "API\n",
+		},
+		{
+			name:      "template actions are escaped",
+			text:      "{{api}}\n",
+			want:      `{{"{{"}}{{.ProjectName}}}}` + "\n",
+			wantLines: []int{1},
+		},
+		{
+			name:      "at start and end",
+			text:      "api",
+			want:      "{{.ProjectName}}",
+			wantLines: []int{1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, replacements := parameterize(test.text, variables)
+			if got != test.want {
+				t.Errorf("parameterize() = %q, want %q", got, test.want)
+			}
+
+			var lines []int
+			for _, replacement := range replacements {
+				lines = append(lines, replacement.Line)
+			}
+			if !slices.Equal(lines, test.wantLines) {
+				t.Errorf("replacement lines = %v, want %v", lines, test.wantLines)
+			}
+		})
+	}
+}
+
+func TestSnapshotShortProjectName(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "api")
+
+	files := map[string]string{
+		"go.mod":          "module example.com/api\n\ngo 1.25\n",
+		"main.go":         "package main\n\nimport \"example.com/api/handlers\"\n\nfunc main() {\n\trapidClient := handlers.NewAPIClient()\n\tserve(\"/v1/\", rapidClient)\n}\n",
+		"handlers/api.go": "package handlers\n",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(projectPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candidates := SnapshotCandidates(projectPath)
+
+	result, err := Snapshot(projectPath, SnapshotOptions{Name: "api", Variables: candidates})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := map[string]string{
+		"go.mod":                       "module {{.ModuleName}}\n\ngo 1.25\n",
+		"main.go":                      "package main\n\nimport \"{{.ModuleName}}/handlers\"\n\nfunc main() {\n\trapidClient := handlers.NewAPIClient()\n\tserve(\"/v1/\", rapidClient)\n}\n",
+		"handlers/{{.ProjectName}}.go": "package handlers\n",
+	}
+	for name, want := range wantFiles {
+		if got := result.Template.Files[name].Content; got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if len(result.Template.Files) != len(wantFiles) {
+		t.Errorf("files = %d, want %d", len(result.Template.Files), len(wantFiles))
+	}
+
+	want := []SnapshotReplacement{
+		{File: "go.mod", Line: 1, Variable: SnapshotVariable{Input: "ModuleName", Literal: "example.com/api"}},
+		{File: "handlers/api.go", Line: 0, Variable: SnapshotVariable{Input: "ProjectName", Literal: "api"}},
+		{File: "main.go", Line: 3, Variable: SnapshotVariable{Input: "ModuleName", Literal: "example.com/api"}},
+	}
+	if !slices.Equal(result.Replacements, want) {
+		t.Errorf("replacements = %+v, want %+v", result.Replacements, want)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "api")
+
+	files := map[string]string{
+		".git/config":   "[core]\n",
+		".gitignore":    "bin/\n*.log\n!keep.log\n",
+		"bin/api":       "built\n",
+		"debug.log":     "debug\n",
+		"keep.log":      "keep\n",
+		"tmp/cache.txt": "cache\n",
+		"go.mod":        "module github.com/acme/api\n",
+		"api.go":        "package api\n\n// {{x}} is kept as it is.\n",
+		"run.sh":        "#!/bin/sh\n",
+		"logo.png":      "\x00\x01",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(projectPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.Chmod(filepath.Join(projectPath, "run.sh"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := SnapshotOptions{
+		Name:    "api",
+		Exclude: []string{"tmp/"},
+		Variables: []SnapshotVariable{
+			{Input: "ProjectName", Literal: "api"},
+			{Input: "ModuleName", Literal: "github.com/acme/api"},
+		},
+	}
+
+	t.Run("skip binary files", func(t *testing.T) {
+		result, err := Snapshot(projectPath, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantFiles := map[string]TemplateFile{
+			".gitignore":          {Content: "bin/\n*.log\n!keep.log\n"},
+			"keep.log":            {Content: "keep\n"},
+			"go.mod":              {Content: "module {{.ModuleName}}\n"},
+			"{{.ProjectName}}.go": {Content: "package {{.ProjectName}}\n\n// {{\"{{\"}}x}} is kept as it is.\n"},
+			"run.sh":              {Content: "#!/bin/sh\n", Executable: true},
+		}
+		if len(result.Template.Files) != len(wantFiles) {
+			t.Errorf("files = %v, want %d files", result.Template.Files, len(wantFiles))
+		}
+		for name, want := range wantFiles {
+			if got := result.Template.Files[name]; got != want {
+				t.Errorf("%s = %+v, want %+v", name, got, want)
+			}
+		}
+
+		if want := []string{"logo.png: binary file"}; !slices.Equal(result.Skipped, want) {
+			t.Errorf("skipped = %v, want %v", result.Skipped, want)
+		}
+
+		// ProjectName is a builtin input and not declared.
+		var inputs []string
+		for _, input := range result.Template.Inputs {
+			inputs = append(inputs, input.Name)
+		}
+		if want := []string{"ModuleName"}; !slices.Equal(inputs, want) {
+			t.Errorf("inputs = %v, want %v", inputs, want)
+		}
+	})
+
+	t.Run("encode binary files", func(t *testing.T) {
+		opts := opts
+		opts.EncodeBinary = true
+
+		result, err := Snapshot(projectPath, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := TemplateFile{Content: "AAE=", Encoding: EncodingBase64}
+		if got := result.Template.Files["logo.png"]; got != want {
+			t.Errorf("logo.png = %+v, want %+v", got, want)
+		}
+		if len(result.Skipped) != 0 {
+			t.Errorf("skipped = %v, want none", result.Skipped)
+		}
+	})
+}
+
+func TestSnapshotCandidates(t *testing.T) {
+	projectPath := filepath.Join(t.TempDir(), "shop")
+
+	files := map[string]string{
+		"go.mod":  "module example.com/shop\n\ngo 1.25\n",
+		"main.go": "package main\n\nconst addr = \":8080\"\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(projectPath, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(projectPath, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []SnapshotVariable{
+		{Input: "ProjectName", Literal: "shop"},
+		{Input: "ModuleName", Literal: "example.com/shop"},
+		{Input: "Port", Literal: "8080", Type: InputTypeInt},
+	}
+	if got := SnapshotCandidates(projectPath); !slices.Equal(got, want) {
+		t.Errorf("SnapshotCandidates() = %+v, want %+v", got, want)
+	}
+}
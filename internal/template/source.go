@@ -0,0 +1,329 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gurleensethi/cradle/internal/config"
+)
+
+// SourceKind describes where a template comes from.
+type SourceKind string
+
+const (
+	SourceEmbedded SourceKind = "embedded"
+	SourceUser     SourceKind = "user"
+	// SourceRegistry is a template version cached from a registry.
+	SourceRegistry SourceKind = "registry"
+)
+
+// Source is a location that templates are loaded from.
+type Source struct {
+	Kind SourceKind
+	// Path is the directory backing the source, empty for embedded templates.
+	Path string
+	// Registry is the path of the registry a cached template version comes from.
+	Registry string
+	// Version is the version of a registry template.
+	Version string
+	fsys    fs.FS
+}
+
+// String returns a human readable description of the source.
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceEmbedded:
+		return "embedded templates"
+	case SourceRegistry:
+		return s.Registry + " version " + s.Version
+	}
+	return s.Path
+}
+
+// Sources returns all template sources in precedence order: user template
+// directories first, in the order they are configured, then the latest
+// versions of registry templates, then the embedded templates.
+func Sources() ([]Source, error) {
+	if templateFS == nil {
+		panic("template.SetTemplateFS must be called before loading templates")
+	}
+
+	var sources []Source
+	for _, dirPath := range config.Get().TemplateDirPaths {
+		sources = append(sources, Source{
+			Kind: SourceUser,
+			Path: dirPath,
+			fsys: os.DirFS(dirPath),
+		})
+	}
+
+	registrySources, err := registrySources()
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, registrySources...)
+
+	embeddedFS, err := fs.Sub(*templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	return append(sources, Source{Kind: SourceEmbedded, fsys: embeddedFS}), nil
+}
+
+// A template is either a single YAML file, <name>.yaml, or a directory
+// <name>/ holding a manifest and the tree of files the template creates.
+const (
+	manifestFileName = "template.yaml"
+	filesDirName     = "files"
+	// renderedFileSuffix marks files of a template directory that are
+	// rendered, all other files are copied verbatim.
+	renderedFileSuffix = ".tmpl"
+)
+
+// hasTemplate reports whether the source defines the template name.
+func (s Source) hasTemplate(name string) (bool, error) {
+	_, fileErr := fs.Stat(s.fsys, name+".yaml")
+	_, dirErr := fs.Stat(s.fsys, path.Join(name, manifestFileName))
+
+	for _, err := range []error{fileErr, dirErr} {
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("read template %s from %s: %w", name, s, err)
+		}
+	}
+
+	if fileErr == nil && dirErr == nil {
+		return false, fmt.Errorf("template %s is defined both as %s.yaml and as directory %s in %s", name, name, name, s)
+	}
+
+	return fileErr == nil || dirErr == nil, nil
+}
+
+// baseRef returns the reference to load a base of a template from the source
+// with: the version of the base recorded with a cached registry version, the
+// base name as it is otherwise.
+func (s Source) baseRef(name string) (string, error) {
+	if s.Kind != SourceRegistry {
+		return name, nil
+	}
+
+	versions, err := readBaseVersions(s.fsys)
+	if err != nil {
+		return "", fmt.Errorf("read base versions from %s: %w", s, err)
+	}
+
+	return JoinVersion(name, versions[name]), nil
+}
+
+// readTemplate parses the template name from the source.
+func (s Source) readTemplate(name string) (*TemplateData, error) {
+	data, err := fs.ReadFile(s.fsys, name+".yaml")
+	if err == nil {
+		return parseTemplate(data)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("read template %s from %s: %w", name, s, err)
+	}
+
+	dirFS, err := fs.Sub(s.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTemplateDir(dirFS)
+}
+
+// findTemplate looks up name in every source and returns the template from
+// the source with the highest precedence, along with the sources it shadows.
+func findTemplate(name string) (*TemplateData, Source, []Source, error) {
+	var (
+		found    *Source
+		shadowed []Source
+	)
+
+	sources, err := Sources()
+	if err != nil {
+		return nil, Source{}, nil, err
+	}
+
+	for _, source := range sources {
+		exists, err := source.hasTemplate(name)
+		if err != nil {
+			return nil, Source{}, nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		if found == nil {
+			found = &source
+		} else {
+			shadowed = append(shadowed, source)
+		}
+	}
+
+	if found == nil {
+		return nil, Source{}, nil, ErrNotExists
+	}
+
+	template, err := found.readTemplate(name)
+	if err != nil {
+		return nil, Source{}, nil, fmt.Errorf("parse template %s from %s: %w", name, found, err)
+	}
+
+	return template, *found, shadowed, nil
+}
+
+// parseTemplateDir parses a template directory: the template.yaml manifest
+// and the files/ tree. Files ending in .tmpl are rendered and created without
+// the suffix, other files are copied verbatim. The manifest may list files of
+// the tree without content to set their options, e.g. `when` or `mode`.
+func parseTemplateDir(fsys fs.FS) (*TemplateData, error) {
+	data, err := fs.ReadFile(fsys, manifestFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := parseTemplate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestFileName, err)
+	}
+
+	if template.Files == nil {
+		template.Files = make(map[string]TemplateFile)
+	}
+
+	treePaths := make(map[string]string)
+
+	err = fs.WalkDir(fsys, filesDirName, func(treePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if treePath == filesDirName && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		// Follow symbolic links to the file they point to.
+		info, err := fs.Stat(fsys, treePath)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", treePath)
+		}
+
+		filePath, rendered := strings.CutSuffix(strings.TrimPrefix(treePath, filesDirName+"/"), renderedFileSuffix)
+
+		if otherPath, exists := treePaths[filePath]; exists {
+			return fmt.Errorf("%s and %s both create %s", otherPath, treePath, filePath)
+		}
+		treePaths[filePath] = treePath
+
+		file := template.Files[filePath]
+		if file.Content != "" {
+			return fmt.Errorf("file %s is defined both in %s and as %s", filePath, manifestFileName, treePath)
+		}
+
+		content, err := fs.ReadFile(fsys, treePath)
+		if err != nil {
+			return err
+		}
+
+		file.Content = string(content)
+		file.Verbatim = !rendered
+		if info.Mode().Perm()&0o111 != 0 {
+			file.Executable = true
+		}
+
+		template.Files[filePath] = file
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// TemplateEntry is a template available in one of the sources.
+type TemplateEntry struct {
+	// Name is the name used to refer to the template, e.g. with `cradle create --template`.
+	Name   string
+	Source Source
+	// Template is the parsed template, nil when Err is set.
+	Template *TemplateData
+	Err      error
+}
+
+// ListTemplates returns every available template sorted by name. Templates
+// shadowed by a source with higher precedence are left out.
+func ListTemplates() ([]TemplateEntry, error) {
+	var entries []TemplateEntry
+	seen := make(map[string]struct{})
+
+	sources, err := Sources()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		dirEntries, err := fs.ReadDir(source.fsys, ".")
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("list templates in %s: %w", source, err)
+		}
+
+		for _, dirEntry := range dirEntries {
+			// Hidden files, such as the base versions of a cached registry
+			// template, are not templates.
+			if strings.HasPrefix(dirEntry.Name(), ".") {
+				continue
+			}
+
+			name, isFile := strings.CutSuffix(dirEntry.Name(), ".yaml")
+			if dirEntry.IsDir() {
+				if _, err := fs.Stat(source.fsys, path.Join(name, manifestFileName)); err != nil {
+					continue
+				}
+			} else if !isFile {
+				continue
+			}
+
+			if _, exists := seen[name]; exists {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			entry := TemplateEntry{Name: name, Source: source}
+
+			var err error
+			entry.Template, err = source.readTemplate(name)
+			if err != nil {
+				entry.Err = err
+			} else {
+				entry.Template.Ref = name
+				entry.Template.Source = source
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b TemplateEntry) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return entries, nil
+}
@@ -0,0 +1,139 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gurleensethi/cradle/internal/config"
+)
+
+func TestParseTemplateDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"template.yaml": {Data: []byte(`version: v1
+name: web
+files:
+  Dockerfile:
+    when: .Docker
+  .env: "PORT=8080\n"
+`)},
+		"files/README.md.tmpl":      {Data: []byte("# {{.ProjectName}}\n")},
+		"files/static/index.html":   {Data: []byte("<p>{{.ProjectName}}</p>\n")},
+		"files/scripts/run.sh.tmpl": {Data: []byte("#!/bin/sh\n"), Mode: 0o755},
+		"files/Dockerfile.tmpl":     {Data: []byte("FROM golang\n")},
+	}
+
+	td, err := parseTemplateDir(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := map[string]TemplateFile{
+		"README.md":         {Content: "# {{.ProjectName}}\n"},
+		"static/index.html": {Content: "<p>{{.ProjectName}}</p>\n", Verbatim: true},
+		"scripts/run.sh":    {Content: "#!/bin/sh\n", Executable: true},
+		"Dockerfile":        {Content: "FROM golang\n", When: ".Docker"},
+		".env":              {Content: "PORT=8080\n"},
+	}
+	if len(td.Files) != len(wantFiles) {
+		t.Errorf("files = %v, want %d files", td.Files, len(wantFiles))
+	}
+	for filePath, want := range wantFiles {
+		if got := td.Files[filePath]; got != want {
+			t.Errorf("%s = %+v, want %+v", filePath, got, want)
+		}
+	}
+}
+
+func TestParseTemplateDirErrors(t *testing.T) {
+	manifest := &fstest.MapFile{Data: []byte("version: v1\nname: web\nfiles:\n  README.md: \"# web\\n\"\n")}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "missing manifest",
+			fsys:    fstest.MapFS{"files/main.go": {Data: []byte("package main\n")}},
+			wantErr: "template.yaml",
+		},
+		{
+			name: "file in the manifest and the tree",
+			fsys: fstest.MapFS{
+				"template.yaml":   manifest,
+				"files/README.md": {Data: []byte("# tree\n")},
+			},
+			wantErr: "file README.md is defined both in template.yaml and as files/README.md",
+		},
+		{
+			name: "rendered and verbatim file",
+			fsys: fstest.MapFS{
+				"template.yaml":    manifest,
+				"files/a.txt":      {Data: []byte("a\n")},
+				"files/a.txt.tmpl": {Data: []byte("a\n")},
+			},
+			wantErr: "both create a.txt",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseTemplateDir(test.fsys)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("parseTemplateDir() error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestDirectoryTemplate(t *testing.T) {
+	initTestTemplates(t, map[string]string{
+		"both": "version: v1\nname: both\n",
+	})
+
+	templateDirPath := filepath.Join(os.Getenv(config.EnvCradleHome), config.CradleTemplatesDir)
+	files := map[string]string{
+		"web/template.yaml":      "version: v1\nname: web\ndescription: A web server\n",
+		"web/files/main.go.tmpl": "package main\n",
+		"both/template.yaml":     "version: v1\nname: both\n",
+		"notes/files/readme.md":  "a directory without a manifest is no template\n",
+	}
+	for name, content := range files {
+		filePath := filepath.Join(templateDirPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	td, err := GetTemplate("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := td.Files["main.go"].Content; got != "package main\n" {
+		t.Errorf("main.go = %q, want the content of files/main.go.tmpl", got)
+	}
+
+	_, err = GetTemplate("both")
+	if err == nil || !strings.Contains(err.Error(), "defined both as both.yaml and as directory both") {
+		t.Errorf("GetTemplate() error = %v, want the template to be ambiguous", err)
+	}
+
+	entries, err := ListTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	if strings.Join(names, " ") != "both web" {
+		t.Errorf("templates = %v, want both and web", names)
+	}
+}
@@ -1,18 +1,17 @@
 package template
 
 import (
-	"bytes"
 	"embed"
 	"errors"
 	"fmt"
-	"io/fs"
+	"maps"
 	"os"
-	"regexp"
+	"path"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/huh"
-	"github.com/charmbracelet/lipgloss"
 	"gopkg.in/yaml.v3"
 )
 
@@ -28,93 +27,122 @@ func SetTemplateFS(fs embed.FS) {
 // ErrNotExists is returned when a requested template is not found.
 var ErrNotExists = errors.New("template does not exist")
 
-// TemplateInputValidation defines validation rules for template inputs.
-type TemplateInputValidation struct {
-	Pattern string   `yaml:"pattern"`
-	Message string   `yaml:"message"`
-	MinLen  *int     `yaml:"min_len,omitempty"`
-	MaxLen  *int     `yaml:"max_len,omitempty"`
-	Min     *float64 `yaml:"min,omitempty"`
-	Max     *float64 `yaml:"max,omitempty"`
+// TemplateFile is a file created by a template. In YAML it is either the
+// file content as a string, or a mapping with the content and file options.
+type TemplateFile struct {
+	Content string `yaml:"content"`
+	// Mode is the permission of the file, e.g. 0755. Defaults to 0644.
+	Mode FileMode `yaml:"mode,omitempty"`
+	// Executable makes the file executable by everyone who can read it.
+	Executable bool `yaml:"executable,omitempty"`
+	// When is a template condition, e.g. `.WithDocker`, the file is only
+	// created when it holds.
+	When string `yaml:"when,omitempty"`
+	// Append adds the content to the end of the same file from a base template instead of replacing it.
+	Append bool `yaml:"append,omitempty"`
+	// Encoding is set to base64 for binary files, which are copied without being rendered.
+	Encoding string `yaml:"encoding,omitempty"`
+	// Verbatim copies the content as it is instead of rendering it.
+	Verbatim bool `yaml:"verbatim,omitempty"`
+
+	// From is the template that defined the file, set when templates are composed.
+	From string `yaml:"-"`
 }
 
-func (tiv *TemplateInputValidation) Validate(inputType, input string) string {
-	if inputType == "float" {
-		numValue, err := strconv.ParseFloat(input, 64)
-		if err != nil {
-			return "must be a valid number (float)"
-		}
+// isRendered reports whether the file is text rendered as a template.
+func (f TemplateFile) isRendered() bool {
+	return f.Encoding == "" && !f.Verbatim
+}
 
-		if tiv.Min != nil && float64(numValue) < *tiv.Min {
-			return fmt.Sprintf("number must be greater than %v", *tiv.Min)
-		}
+func (tf *TemplateFile) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*tf = TemplateFile{}
+		return node.Decode(&tf.Content)
+	}
 
-		if tiv.Max != nil && float64(numValue) > *tiv.Max {
-			return fmt.Sprintf("number must be less than %v", *tiv.Max)
-		}
+	err := checkKnownFields(node, tf)
+	if err != nil {
+		return err
 	}
 
-	if inputType == "int" {
-		numValue, err := strconv.Atoi(input)
-		if err != nil {
-			return "must be a valid number (integer)"
-		}
+	type rawTemplateFile TemplateFile
+	return node.Decode((*rawTemplateFile)(tf))
+}
 
-		if tiv.Min != nil && numValue < int(*tiv.Min) {
-			return fmt.Sprintf("number must be greater than %v", *tiv.Min)
-		}
+func (tf TemplateFile) MarshalYAML() (any, error) {
+	// Files without options are written in the short form.
+	if tf == (TemplateFile{Content: tf.Content, From: tf.From}) {
+		return tf.Content, nil
+	}
 
-		if tiv.Max != nil && numValue > int(*tiv.Max) {
-			return fmt.Sprintf("number must be less than %v", *tiv.Max)
-		}
+	type rawTemplateFile TemplateFile
+	return rawTemplateFile(tf), nil
+}
+
+// Perm returns the permission bits the file is written with.
+func (tf TemplateFile) Perm() os.FileMode {
+	perm := os.FileMode(0o644)
+	if tf.Mode != 0 {
+		perm = os.FileMode(tf.Mode)
 	}
 
-	if inputType == "string" {
-		if tiv.Pattern != "" {
-			regex, err := regexp.Compile(tiv.Pattern)
-			if err != nil {
-				return fmt.Sprintf("invalid regex: %v %v", err, tiv.Pattern)
-			}
+	if tf.Executable {
+		// Grant execute to everyone who can read the file.
+		perm |= (perm & 0o444) >> 2
+	}
 
-			if !regex.MatchString(input) {
-				return fmt.Sprintf("should match pattern `%s`", tiv.Pattern)
-			}
-		}
+	return perm
+}
 
-		if tiv.MinLen != nil {
-			if len(input) < *tiv.MinLen {
-				return fmt.Sprintf("need to be at least %d characters", *tiv.MinLen)
-			}
-		}
+// FileMode is a file permission written in octal, e.g. 0755.
+type FileMode os.FileMode
 
-		if tiv.MaxLen != nil {
-			if len(input) > *tiv.MaxLen {
-				return fmt.Sprintf("cannot be longer than %d characters", *tiv.MaxLen)
-			}
-		}
+func (fm *FileMode) UnmarshalYAML(node *yaml.Node) error {
+	value := strings.TrimPrefix(strings.TrimPrefix(node.Value, "0o"), "0O")
+
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("line %d: invalid file mode %q, expected an octal permission such as 0644", node.Line, node.Value)
 	}
 
-	return ""
+	*fm = FileMode(mode)
+	return nil
 }
 
-// TemplateInput defines a user input field for a template.
-type TemplateInput struct {
-	Name        string                  `yaml:"name"`
-	Default     string                  `yaml:"default"`
-	Required    bool                    `yaml:"required"`
-	Description string                  `yaml:"description"`
-	Pattern     string                  `yaml:"pattern"`
-	Type        string                  `yaml:"type"`
-	Validate    TemplateInputValidation `yaml:"validate,omitempty"`
+func (fm FileMode) MarshalYAML() (any, error) {
+	return fmt.Sprintf("%04o", uint32(fm)), nil
 }
 
-type TemplateFile string
-
 type TemplateData struct {
+	// Version is the schema version of the template format, see CurrentSchemaVersion.
+	Version     string                  `yaml:"version"`
 	Name        string                  `yaml:"name"`
 	Description string                  `yaml:"description"`
 	Inputs      []TemplateInput         `yaml:"inputs"`
 	Files       map[string]TemplateFile `yaml:"files"`
+	Hooks       TemplateHooks           `yaml:"hooks,omitempty"`
+	// Extends names a template this template is based on.
+	Extends string `yaml:"extends,omitempty"`
+	// Include names templates whose inputs, files and hooks are mixed into this template.
+	Include []string `yaml:"include,omitempty"`
+
+	// Ref is the template name, or the file path for templates loaded from a file.
+	Ref string `yaml:"-"`
+	// Source is where the template was loaded from.
+	Source Source `yaml:"-"`
+	// Warnings are non-fatal problems found while loading the template.
+	Warnings []string `yaml:"-"`
+	// Parts are the templates the resolved template is composed of, in the
+	// order they were merged and ending with the template itself.
+	Parts []TemplatePart `yaml:"-"`
+}
+
+// TemplatePart is a template contributing to a resolved template.
+type TemplatePart struct {
+	Ref    string
+	Source Source
+	// Hooks are the hooks declared by this template alone.
+	Hooks TemplateHooks
 }
 
 func (td *TemplateData) Validate() error {
@@ -126,105 +154,124 @@ func (td *TemplateData) Validate() error {
 		return errors.New("template must define at least one file")
 	}
 
+	for _, filePath := range slices.Sorted(maps.Keys(td.Files)) {
+		// Templated paths can only be checked once they are rendered.
+		if strings.Contains(filePath, "{{") {
+			continue
+		}
+
+		if _, err := cleanFilePath(filePath); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// GetTemplate loads a template by name from the embedded filesystem.
-func GetTemplate(templateName string) (*TemplateData, error) {
-	if templateFS == nil {
-		panic("template.SetTemplateFS must be called before GetTemplate")
-	}
+// cleanFilePath normalizes a slash separated file path of a template file,
+// rejecting paths that would be created outside the project directory.
+func cleanFilePath(filePath string) (string, error) {
+	cleaned := path.Clean(filePath)
 
-	data, err := (*templateFS).ReadFile("templates/" + templateName + ".yaml")
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil, ErrNotExists
-		}
+	if filePath == "" || cleaned == "." {
+		return "", fmt.Errorf("file path %q does not name a file", filePath)
+	}
 
-		return nil, err
+	if path.IsAbs(filePath) || filepath.IsAbs(filePath) || filepath.VolumeName(filePath) != "" ||
+		cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("file path %q must stay inside the project directory", filePath)
 	}
 
-	var template TemplateData
-	buffer := bytes.NewBuffer(data)
+	return cleaned, nil
+}
 
-	yamlDecoder := yaml.NewDecoder(buffer)
+// GetTemplate loads a template by name and resolves the templates it extends
+// and includes. User template directories take precedence over the embedded
+// templates; when the name exists in more than one source, the shadowed
+// sources are reported in the template's Warnings.
+func GetTemplate(templateName string) (*TemplateData, error) {
+	template, err := loadTemplate(templateName)
+	if err != nil {
+		return nil, err
+	}
 
-	err = yamlDecoder.Decode(&template)
+	err = template.resolve([]string{templateName})
 	if err != nil {
 		return nil, err
 	}
 
-	return &template, nil
+	return template, nil
 }
 
-// ReadUserInputs collects validated input values from the user.
-func ReadUserInputs(td *TemplateData) (map[string]string, error) {
-	userInputs := make(map[string]string)
-	for _, input := range td.Inputs {
-		userInputs[input.Name] = input.Default
+// loadTemplate loads a template by name without resolving its base templates.
+// A name such as go-service@1.2.0 loads that cached version of a registry template.
+func loadTemplate(templateName string) (*TemplateData, error) {
+	name, version := SplitVersion(templateName)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid template name %q", templateName)
 	}
 
-	if len(td.Inputs) == 0 {
-		return userInputs, nil
+	if version != "" {
+		source, err := findPinnedTemplate(name, version)
+		if err != nil {
+			return nil, err
+		}
+
+		template, err := source.readTemplate(name)
+		if err != nil {
+			return nil, fmt.Errorf("parse template %s from %s: %w", templateName, source, err)
+		}
+
+		template.Ref = templateName
+		template.Source = source
+
+		return template, nil
 	}
 
-	fmt.Println(
-		lipgloss.NewStyle().
-			Margin(1).
-			Underline(true).
-			Render(td.Name + " - " + td.Description),
-	)
+	template, source, shadowed, err := findTemplate(templateName)
+	if err != nil {
+		return nil, err
+	}
 
-	var fields []huh.Field
-	for _, input := range td.Inputs {
-		title := input.Name
-		if input.Required {
-			title += "*"
-		}
+	template.Ref = templateName
+	template.Source = source
+	for _, shadowedSource := range shadowed {
+		template.Warnings = append(template.Warnings,
+			fmt.Sprintf("template %s from %s shadows the one from %s", templateName, source, shadowedSource))
+	}
+
+	return template, nil
+}
+
+// LoadTemplateFile loads a template from a YAML file or a template directory on disk.
+func LoadTemplateFile(filePath string) (*TemplateData, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-		description := input.Description
-		if input.Default != "" {
-			description += "\n(default: " + input.Default + ")"
+	var template *TemplateData
+	if info.IsDir() {
+		template, err = parseTemplateDir(os.DirFS(filePath))
+	} else {
+		var data []byte
+		data, err = os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
 		}
+		template, err = parseTemplate(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", filePath, err)
+	}
+
+	template.Ref = filePath
+	template.Source = Source{Kind: SourceUser, Path: filePath}
 
-		field := huh.NewInput().
-			Title(title).
-			Description(description).
-			PlaceholderFunc(func() string {
-				return input.Default
-			}, nil).
-			Validate(func(s string) error {
-				if s == "" {
-					if input.Default != "" {
-						userInputs[input.Name] = input.Default
-						return nil
-					} else if input.Required {
-						return errors.New(input.Name + " is required")
-					}
-				} else {
-					validationMessage := input.Validate.Validate(input.Type, s)
-					if validationMessage != "" {
-						return errors.New(input.Name + " " + validationMessage)
-					}
-				}
-
-				userInputs[input.Name] = s
-
-				return nil
-			}).
-			Key(input.Name)
-
-		fields = append(fields, field)
-	}
-
-	form := huh.NewForm(
-		huh.NewGroup(fields...),
-	).WithProgramOptions(tea.WithOutput(os.Stdout))
-
-	err := form.Run()
+	err = template.resolve([]string{filePath})
 	if err != nil {
 		return nil, err
 	}
 
-	return userInputs, nil
+	return template, nil
 }
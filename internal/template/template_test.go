@@ -0,0 +1,123 @@
+package template
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// initTestTemplates initializes the config in a new cradle home holding the
+// user templates, by name, and no embedded templates.
+func initTestTemplates(t *testing.T, templates map[string]string) {
+	t.Helper()
+
+	homePath := t.TempDir()
+	t.Setenv(config.EnvCradleHome, homePath)
+
+	err := config.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTemplateFS(embed.FS{})
+
+	templateDirPath := filepath.Join(homePath, config.CradleTemplatesDir)
+	err = os.MkdirAll(templateDirPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range templates {
+		err = os.WriteFile(filepath.Join(templateDirPath, name+".yaml"), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTemplateFilePerm(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want os.FileMode
+	}{
+		{name: "content only", yaml: `"text"`, want: 0o644},
+		{name: "mode", yaml: `{content: text, mode: 0600}`, want: 0o600},
+		{name: "mode with 0o prefix", yaml: `{content: text, mode: 0o700}`, want: 0o700},
+		{name: "executable", yaml: `{content: text, executable: true}`, want: 0o755},
+		{name: "executable with mode", yaml: `{content: text, mode: 0640, executable: true}`, want: 0o750},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var file TemplateFile
+
+			err := yaml.Unmarshal([]byte(test.yaml), &file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if file.Content != "text" {
+				t.Errorf("content = %q, want %q", file.Content, "text")
+			}
+			if got := file.Perm(); got != test.want {
+				t.Errorf("Perm() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFileModeInvalid(t *testing.T) {
+	for _, mode := range []string{"0999", "01777", "rw-r--r--", "-1"} {
+		t.Run(mode, func(t *testing.T) {
+			var file TemplateFile
+
+			err := yaml.Unmarshal([]byte("{content: text, mode: "+mode+"}"), &file)
+			if err == nil {
+				t.Errorf("mode %s was accepted as %s, want an error", mode, os.FileMode(file.Mode))
+			}
+		})
+	}
+}
+
+func TestCleanFilePath(t *testing.T) {
+	tests := []struct {
+		filePath string
+		// want is the cleaned path, empty when the path must be rejected.
+		want string
+	}{
+		{filePath: "main.go", want: "main.go"},
+		{filePath: "cmd/api/main.go", want: "cmd/api/main.go"},
+		{filePath: "./docs//guide/../README.md", want: "docs/README.md"},
+		{filePath: ""},
+		{filePath: "."},
+		{filePath: "docs/.."},
+		{filePath: ".."},
+		{filePath: "../outside.txt"},
+		{filePath: "docs/../../outside.txt"},
+		{filePath: "/etc/passwd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filePath, func(t *testing.T) {
+			got, err := cleanFilePath(test.filePath)
+
+			if test.want == "" {
+				if err == nil {
+					t.Errorf("cleanFilePath(%q) = %q, want an error", test.filePath, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("cleanFilePath(%q) = %q, want %q", test.filePath, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package template
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/gurleensethi/cradle/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// versionsDirName is the directory in the state directory keeping the template
+// versions projects were rendered from, so they can be rendered again when a
+// project is upgraded.
+const versionsDirName = "template_versions"
+
+// Version returns the version of the resolved template, a hash of its content.
+func Version(td *TemplateData) (string, error) {
+	_, version, err := marshalVersion(td)
+	return version, err
+}
+
+// SaveVersion stores the resolved template under its version and returns the version.
+func SaveVersion(td *TemplateData) (string, error) {
+	data, version, err := marshalVersion(td)
+	if err != nil {
+		return "", err
+	}
+
+	versionFilePath := versionFilePath(td.Ref, version)
+
+	// Versions are content addressed, an existing file holds the same template.
+	if _, err := os.Stat(versionFilePath); err == nil {
+		return version, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(versionFilePath), 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.WriteFile(versionFilePath, data, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("save version %s of template %s: %w", version, td.Ref, err)
+	}
+
+	return version, nil
+}
+
+// LoadVersion loads a template version stored by SaveVersion.
+func LoadVersion(name, version string) (*TemplateData, error) {
+	versionFilePath := versionFilePath(name, version)
+
+	data, err := os.ReadFile(versionFilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("version %s of template %s is not stored in %s", version, name, filepath.Dir(versionFilePath))
+		}
+		return nil, err
+	}
+
+	template, err := parseTemplate(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse version %s of template %s: %w", version, name, err)
+	}
+
+	template.Ref = name
+	template.Source = Source{Kind: SourceUser, Path: versionFilePath}
+
+	return template, nil
+}
+
+// marshalVersion encodes the resolved template in the format it is stored
+// in and returns the encoding and the version derived from it.
+func marshalVersion(td *TemplateData) ([]byte, string, error) {
+	version := TemplateData{
+		Version:     CurrentSchemaVersion,
+		Name:        td.Name,
+		Description: td.Description,
+		Inputs:      td.Inputs,
+		Files:       make(map[string]TemplateFile, len(td.Files)),
+		Hooks:       td.Hooks,
+	}
+
+	for filePath, file := range td.Files {
+		// YAML only holds text, binary files are stored encoded.
+		if file.Verbatim && !utf8.ValidString(file.Content) {
+			file.Content = base64.StdEncoding.EncodeToString([]byte(file.Content))
+			file.Encoding = EncodingBase64
+			file.Verbatim = false
+		}
+		version.Files[filePath] = file
+	}
+
+	var buf bytes.Buffer
+
+	yamlEncoder := yaml.NewEncoder(&buf)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(version); err != nil {
+		return nil, "", err
+	}
+	if err := yamlEncoder.Close(); err != nil {
+		return nil, "", err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+
+	return buf.Bytes(), hex.EncodeToString(sum[:])[:12], nil
+}
+
+func versionFilePath(name, version string) string {
+	return filepath.Join(config.Get().StateDirPath, versionsDirName, name, version+".yaml")
+}
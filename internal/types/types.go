@@ -15,6 +15,61 @@ type CradleProject struct {
 	// UniqueNameFromPath is a display name derived from the project path (not serialized to YAML).
 	UniqueNameFromPath string `yaml:"-"`
 	CreatedBy          string `yaml:"created_by"`
+	// Template is the name of the template the project was created from.
+	Template string `yaml:"template,omitempty"`
+	// Templates records the templates applied to the project and the inputs they were applied with.
+	Templates []AppliedTemplate `yaml:"templates,omitempty"`
+}
+
+// AppliedTemplate is a template rendered into a project.
+type AppliedTemplate struct {
+	Name string `yaml:"name"`
+	// Version identifies the content of the template that was rendered, see template.SaveVersion.
+	Version string `yaml:"version,omitempty"`
+	// Inputs are the input values as they would be passed with --set, secrets are never recorded.
+	Inputs    map[string]string `yaml:"inputs,omitempty"`
+	AppliedAt time.Time         `yaml:"applied_at"`
+	// Generated are the values now, year and uuid returned when the template
+	// was first rendered, so an upgrade renders the same ones.
+	Generated Generated `yaml:"generated,omitempty"`
+}
+
+// Generated records the results of the template functions returning
+// something new on every call.
+type Generated struct {
+	// Time is the time returned by now and year.
+	Time time.Time `yaml:"time"`
+	// UUIDs are the UUIDs returned by uuid in call order, by the name of the
+	// file or input that called it.
+	UUIDs map[string][]string `yaml:"uuids,omitempty"`
+	// Git are the values returned by gitUser, gitEmail and githubUser by git
+	// config key, e.g. user.name. They are read from the git config when nil.
+	Git map[string]string `yaml:"git,omitempty"`
+	// Sequential makes uuid return UUIDs numbered by the file or input name
+	// and call order instead of random ones, so renders such as template
+	// tests are reproducible.
+	Sequential bool `yaml:"-"`
+}
+
+// AppliedTemplate returns the record of the template applied to the project under name.
+func (p CradleProject) AppliedTemplate(name string) (AppliedTemplate, bool) {
+	for _, applied := range p.Templates {
+		if applied.Name == name {
+			return applied, true
+		}
+	}
+	return AppliedTemplate{}, false
+}
+
+// SetAppliedTemplate records a template application, replacing an earlier one of the same template.
+func (p *CradleProject) SetAppliedTemplate(applied AppliedTemplate) {
+	for i := range p.Templates {
+		if p.Templates[i].Name == applied.Name {
+			p.Templates[i] = applied
+			return
+		}
+	}
+	p.Templates = append(p.Templates, applied)
 }
 
 // MatchPathOrName reports whether the project's path or unique name exactly matches the query.
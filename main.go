@@ -13,7 +13,7 @@ import (
 	"github.com/urfave/cli/v3"
 )
 
-//go:embed templates/*.yaml
+//go:embed all:templates
 var templateFS embed.FS
 
 func main() {
@@ -44,11 +44,14 @@ func main() {
 		Commands: []*cli.Command{
 			command.List(),
 			command.Create(),
+			command.Apply(),
 			command.Add(),
 			command.Remove(),
 			command.Open(),
 			command.Cleanup(),
 			command.Doctor(),
+			command.Template(),
+			command.Config(),
 		},
 	}
 
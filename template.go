@@ -1,8 +1,6 @@
 package main
 
-import (
-	"github.com/gurleensethi/cradle/internal/template"
-)
+import "github.com/gurleensethi/cradle/internal/template"
 
 // Deprecated: Use template.TemplateInputValidation instead.
 type TemplateInputValidation = template.TemplateInputValidation
@@ -21,9 +19,16 @@ func GetTemplate(templateName string) (*template.TemplateData, error) {
 	return template.GetTemplate(templateName)
 }
 
-// Deprecated: Use template.ReadUserInputs instead.
+// Deprecated: Use template.ReadUserInputs instead, it returns typed values.
+// The values are returned as text, lists separated by commas. Secret and
+// computed inputs are left out, as they are when a project records its inputs.
 func ReadUserInputs(td *template.TemplateData) (map[string]string, error) {
-	return template.ReadUserInputs(td)
+	inputs, err := template.ReadUserInputs(td)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.FormatInputs(td, inputs), nil
 }
 
 // Deprecated: Use template.ErrNotExists instead.
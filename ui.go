@@ -17,6 +17,23 @@ type CradleUIModel struct {
 	ProjectList         list.Model
 	Width               int
 	Height              int
+	Theme               uiTheme
+}
+
+// uiTheme holds the colors of a TUI theme, see the theme setting.
+type uiTheme struct {
+	// Accent colors the title bar and the names of projects.
+	Accent lipgloss.Color
+	// Border marks the selected project.
+	Border lipgloss.Color
+}
+
+var uiThemes = map[string]uiTheme{
+	"orange": {Accent: "#ff7300", Border: "209"},
+	"blue":   {Accent: "#1e90ff", Border: "75"},
+	"green":  {Accent: "#2e8b57", Border: "78"},
+	"purple": {Accent: "#8a2be2", Border: "141"},
+	"mono":   {Accent: "#808080", Border: "245"},
 }
 
 type ProjectListItem struct {
@@ -31,7 +48,9 @@ func (p ProjectListItem) FilterValue() string {
 	return p.Project.UniqueNameFromPath + " " + p.Project.Path
 }
 
-type ProjectListDelegate struct{}
+type ProjectListDelegate struct {
+	Theme uiTheme
+}
 
 func (p ProjectListDelegate) Height() int { return 3 }
 
@@ -52,7 +71,7 @@ func (p ProjectListDelegate) Render(w io.Writer, m list.Model, index int, item l
 		Faint(true).
 		Foreground(lipgloss.AdaptiveColor{
 			Light: "0",
-			Dark:  "#ff7300",
+			Dark:  string(p.Theme.Accent),
 		})
 	selectedTitle := nonSelectedTitle.Bold(true).
 		Faint(false)
@@ -83,8 +102,8 @@ func (p ProjectListDelegate) Render(w io.Writer, m list.Model, index int, item l
 			}).
 			Border(lipgloss.NormalBorder(), false, false, false, true).
 			BorderForeground(lipgloss.AdaptiveColor{
-				Light: "209",
-				Dark:  "209",
+				Light: string(p.Theme.Border),
+				Dark:  string(p.Theme.Border),
 			})
 
 		titleStyle = selectedTitle
@@ -123,13 +142,16 @@ func NewCradleUIModel() CradleUIModel {
 		listItems = append(listItems, ProjectListItem{Project: project})
 	}
 
-	projectList := list.New(listItems, ProjectListDelegate{}, 0, 0)
+	theme := uiThemes[config.Get().Settings.Theme]
+
+	projectList := list.New(listItems, ProjectListDelegate{Theme: theme}, 0, 0)
 	projectList.SetShowTitle(false)
 	projectList.FilterInput.Prompt = "Search: "
 	projectList.FilterInput.PromptStyle = lipgloss.NewStyle()
 
 	return CradleUIModel{
 		ProjectList: projectList,
+		Theme:       theme,
 	}
 }
 
@@ -178,7 +200,7 @@ func (c CradleUIModel) Title() string {
 		MarginBottom(1).
 		Bold(true).
 		Align(lipgloss.Center).
-		Background(lipgloss.Color("#ff7300")).
+		Background(c.Theme.Accent).
 		Foreground(lipgloss.Color("#FFFFFF")).
 		Render("cradle")
 }